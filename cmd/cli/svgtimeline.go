@@ -6,6 +6,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	svgtimeline "github.com/aorith/svg-timeline"
 )
@@ -14,11 +16,12 @@ func main() {
 	var (
 		inputFile  = flag.String("i", "", "Input CFG file (required)")
 		cssFile    = flag.String("s", "", "CSS style file (optional)")
-		outputFile = flag.String("o", "", "Output SVG file (default: stdout)")
+		outputFile = flag.String("o", "", "Output SVG file, an HTML page or the JSON layout is written when it ends in .html or .json (default: stdout)")
+		info       = flag.Bool("info", false, "Print the SVG dimensions and counts to stderr without writing the SVG unless -o is given")
 	)
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s -i <input.cfg> [-s <style.css>] [-o <output.svg>]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s -i <input.cfg> [-s <style.css>] [-o <output.svg>] [-info]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Generate SVG timeline from CFG file.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
@@ -33,9 +36,37 @@ func main() {
 		os.Exit(1)
 	}
 
-	svg, err := svgtimeline.GenerateFromCFG(*inputFile, *cssFile)
+	tl, err := svgtimeline.NewTimelineFromCFG(*inputFile, *cssFile)
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "Error reading config file: %v\n", err)
+		os.Exit(1)
+	}
+	var svg string
+	switch {
+	case strings.HasSuffix(*outputFile, ".html"):
+		svg, err = tl.GenerateHTML(strings.TrimSuffix(filepath.Base(*outputFile), ".html"))
+	case strings.HasSuffix(*outputFile, ".json"):
+		var data []byte
+		data, err = tl.GenerateJSON()
+		svg = string(data)
+	default:
+		svg, err = tl.Generate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating timeline: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *info {
+		w, h := tl.Dimensions()
+		events := 0
+		for _, row := range tl.GetRows() {
+			events += len(row.GetEvents())
+		}
+		fmt.Fprintf(os.Stderr, "Width: %g\nHeight: %g\nRows: %d\nEvents: %d\n", w, h, len(tl.GetRows()), events)
+		if *outputFile == "" {
+			return
+		}
 	}
 
 	// Write output
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: MIT
+
+package svgtimeline
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GeneratePalette returns n visually distinct colors in hex notation
+//
+// The hues are evenly spaced around the HSL color wheel with a fixed saturation
+// and lightness, so the same n always yields the same palette.
+func GeneratePalette(n int) []string {
+	colors := make([]string, 0, max(n, 0))
+	for i := range n {
+		r, g, b := hslToRGB(float64(i)*360/float64(n), 0.65, 0.55)
+		colors = append(colors, fmt.Sprintf("#%02x%02x%02x", int(math.Round(r*255)), int(math.Round(g*255)), int(math.Round(b*255))))
+	}
+	return colors
+}
+
+// hslToRGB converts a color from HSL, with the hue in degrees and the saturation
+// and lightness between 0 and 1, to its red, green and blue channels between 0 and 1
+func hslToRGB(h, s, l float64) (r, g, b float64) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return r + m, g + m, b + m
+}
+
+// contrastText returns the text color readable on top of the given fill
+//
+// Only hex (#rgb, #rrggbb) and rgb()/rgba() colors are understood, false is
+// returned for any other value.
+func contrastText(fill string) (string, bool) {
+	r, g, b, ok := parseColor(fill)
+	if !ok {
+		return "", false
+	}
+	// Relative luminance as defined by WCAG 2
+	channel := func(c float64) float64 {
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	lum := 0.2126*channel(r) + 0.7152*channel(g) + 0.0722*channel(b)
+	// Threshold where black and white text have the same contrast ratio
+	if lum > 0.179 {
+		return "#000000", true
+	}
+	return "#ffffff", true
+}
+
+// parseColor returns the red, green and blue channels of a CSS color between 0 and 1
+func parseColor(s string) (r, g, b float64, ok bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case strings.HasPrefix(s, "#"):
+		hex := s[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return 0, 0, 0, false
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		return float64(v>>16&0xff) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255, true
+
+	case strings.HasPrefix(s, "rgb(") || strings.HasPrefix(s, "rgba("):
+		args := s[strings.Index(s, "(")+1:]
+		args, found := strings.CutSuffix(args, ")")
+		if !found {
+			return 0, 0, 0, false
+		}
+		parts := strings.Split(args, ",")
+		if len(parts) < 3 {
+			return 0, 0, 0, false
+		}
+		var channels [3]float64
+		for i := range channels {
+			v, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+			if err != nil {
+				return 0, 0, 0, false
+			}
+			channels[i] = min(max(v, 0), 255) / 255
+		}
+		return channels[0], channels[1], channels[2], true
+	}
+	return 0, 0, 0, false
+}
@@ -11,6 +11,7 @@ type svg struct {
 	ID                  string   `xml:"id,attr,omitempty"`
 	Class               string   `xml:"class,attr,omitempty"`
 	Xmlns               string   `xml:"xmlns,attr"`
+	XmlnsXlink          string   `xml:"xmlns:xlink,attr,omitempty"`
 	Width               string   `xml:"width,attr"`
 	Height              string   `xml:"height,attr"`
 	ViewBox             string   `xml:"viewBox,attr"`
@@ -21,6 +22,7 @@ type svg struct {
 type svgDefs struct {
 	XMLName  xml.Name `xml:"defs"`
 	Elements []any    `xml:",any"`
+	Content  string   `xml:",innerxml"`
 }
 
 type svgStyle struct {
@@ -29,9 +31,29 @@ type svgStyle struct {
 }
 
 type g struct {
-	XMLName  xml.Name `xml:"g"`
-	ID       string   `xml:"id,attr,omitempty"`
-	Class    string   `xml:"class,attr,omitempty"`
+	XMLName     xml.Name `xml:"g"`
+	ID          string   `xml:"id,attr,omitempty"`
+	Class       string   `xml:"class,attr,omitempty"`
+	DataGroup   string   `xml:"data-group,attr,omitempty"`
+	DataTooltip string   `xml:"data-tooltip,attr,omitempty"`
+	ClipPath    string   `xml:"clip-path,attr,omitempty"`
+	Elements    []any    `xml:",any"`
+}
+
+type clipPath struct {
+	XMLName  xml.Name `xml:"clipPath"`
+	ID       string   `xml:"id,attr"`
+	Elements []any    `xml:",any"`
+}
+
+type script struct {
+	XMLName xml.Name `xml:"script"`
+	Content string   `xml:",innerxml"`
+}
+
+type anchor struct {
+	XMLName  xml.Name `xml:"a"`
+	Href     string   `xml:"xlink:href,attr"`
 	Elements []any    `xml:",any"`
 }
 
@@ -43,11 +65,37 @@ type rect struct {
 	Y               float64  `xml:"y,attr"`
 	Width           float64  `xml:"width,attr"`
 	Height          float64  `xml:"height,attr"`
+	Rx              float64  `xml:"rx,attr,omitempty"`
+	Ry              float64  `xml:"ry,attr,omitempty"`
 	Fill            string   `xml:"fill,attr,omitempty"`
 	FillOpacity     float64  `xml:"fill-opacity,attr,omitempty"`
 	Stroke          string   `xml:"stroke,attr,omitempty"`
 	StrokeWidth     int      `xml:"stroke-width,attr,omitempty"`
 	StrokeDasharray string   `xml:"stroke-dasharray,attr,omitempty"`
+	Style           string   `xml:"style,attr,omitempty"`
+}
+
+type polygon struct {
+	XMLName         xml.Name `xml:"polygon"`
+	ID              string   `xml:"id,attr,omitempty"`
+	Class           string   `xml:"class,attr,omitempty"`
+	Points          string   `xml:"points,attr"`
+	Fill            string   `xml:"fill,attr,omitempty"`
+	FillOpacity     float64  `xml:"fill-opacity,attr,omitempty"`
+	Stroke          string   `xml:"stroke,attr,omitempty"`
+	StrokeWidth     int      `xml:"stroke-width,attr,omitempty"`
+	StrokeDasharray string   `xml:"stroke-dasharray,attr,omitempty"`
+	Style           string   `xml:"style,attr,omitempty"`
+}
+
+type circle struct {
+	XMLName xml.Name `xml:"circle"`
+	Class   string   `xml:"class,attr,omitempty"`
+	Cx      float64  `xml:"cx,attr"`
+	Cy      float64  `xml:"cy,attr"`
+	R       float64  `xml:"r,attr"`
+	Fill    string   `xml:"fill,attr,omitempty"`
+	Style   string   `xml:"style,attr,omitempty"`
 }
 
 type line struct {
@@ -80,7 +128,16 @@ type text struct {
 	DominantBaseline string   `xml:"dominant-baseline,attr,omitempty"`
 	WritingMode      string   `xml:"writing-mode,attr,omitempty"`
 	Transform        string   `xml:"transform,attr,omitempty"`
+	Style            string   `xml:"style,attr,omitempty"`
 	Content          string   `xml:",chardata"`
+	Spans            []tspan  `xml:"tspan"`
+}
+
+type tspan struct {
+	XMLName xml.Name `xml:"tspan"`
+	X       float64  `xml:"x,attr"`
+	Dy      string   `xml:"dy,attr,omitempty"`
+	Content string   `xml:",chardata"`
 }
 
 type title struct {
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: MIT
+
+package svgtimeline
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// DurationFormat selects how durations are rendered in the tick labels
+type DurationFormat int
+
+const (
+	DurationFormatGo          DurationFormat = iota // Go duration strings such as 1h2m3s
+	DurationFormatClock                             // Clock style such as 01:02:03
+	DurationFormatDecimalUnit                       // Decimal value of the largest unit such as 1.04h
+)
+
+// decimalUnits lists the units used by DurationFormatDecimalUnit from largest to smallest
+var decimalUnits = []struct {
+	unit   time.Duration
+	suffix string
+}{
+	{time.Hour, "h"},
+	{time.Minute, "m"},
+	{time.Second, "s"},
+	{time.Millisecond, "ms"},
+	{time.Microsecond, "µs"},
+	{time.Nanosecond, "ns"},
+}
+
+// formatDurationStyle formats a duration with the given style rounded to the given digits
+//
+// The locale only affects the decimal numbers of DurationFormatDecimalUnit, language.Und
+// keeps the default dot-separated formatting.
+func formatDurationStyle(d time.Duration, style DurationFormat, digits int, locale language.Tag) string {
+	switch style {
+	case DurationFormatClock:
+		return formatClock(d, digits)
+	case DurationFormatDecimalUnit:
+		return formatDecimalUnit(d, digits, locale)
+	}
+	return formatDuration(d, digits)
+}
+
+// formatDuration rounds a time.Duration to the given digits and returns its String()
+func formatDuration(d time.Duration, digits int) string {
+	div := time.Duration(math.Pow(10, float64(digits)))
+	switch {
+	case d > time.Second:
+		d = d.Round(time.Second / div)
+	case d > time.Millisecond:
+		d = d.Round(time.Millisecond / div)
+	case d > time.Microsecond:
+		d = d.Round(time.Microsecond / div)
+	case d > time.Nanosecond:
+		d = d.Round(time.Nanosecond / div)
+	}
+	return d.String()
+}
+
+// formatClock formats a duration as hh:mm:ss with up to the given fractional second digits
+func formatClock(d time.Duration, digits int) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	d = d.Round(time.Second / time.Duration(math.Pow(10, float64(digits))))
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
+	sec := float64(d%time.Minute) / float64(time.Second)
+	secs := trimDecimals(strconv.FormatFloat(sec, 'f', digits, 64))
+	if sec < 10 {
+		secs = "0" + secs
+	}
+	return fmt.Sprintf("%s%02d:%02d:%s", sign, h, m, secs)
+}
+
+// formatDecimalUnit formats a duration as a decimal value of its largest unit
+func formatDecimalUnit(d time.Duration, digits int, locale language.Tag) string {
+	if d == 0 {
+		return "0s"
+	}
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+	u := decimalUnits[len(decimalUnits)-1]
+	for _, du := range decimalUnits {
+		if abs >= du.unit {
+			u = du
+			break
+		}
+	}
+	v := float64(d) / float64(u.unit)
+	if locale != language.Und {
+		p := message.NewPrinter(locale)
+		return p.Sprint(number.Decimal(v, number.MaxFractionDigits(digits), number.NoSeparator())) + u.suffix
+	}
+	return trimDecimals(strconv.FormatFloat(v, 'f', digits, 64)) + u.suffix
+}
+
+// unitSuffix returns the suffix of one of the decimalUnits
+func unitSuffix(unit time.Duration) (string, bool) {
+	for _, du := range decimalUnits {
+		if du.unit == unit {
+			return du.suffix, true
+		}
+	}
+	return "", false
+}
+
+// formatFixedUnit formats a duration as a decimal value of the given unit such as 10800s
+//
+// Unlike formatDecimalUnit the digits of large values are grouped when a locale is set.
+func formatFixedUnit(d, unit time.Duration, digits int, locale language.Tag) string {
+	suffix, _ := unitSuffix(unit)
+	v := float64(d) / float64(unit)
+	if locale != language.Und {
+		p := message.NewPrinter(locale)
+		return p.Sprint(number.Decimal(v, number.MaxFractionDigits(digits))) + suffix
+	}
+	return trimDecimals(strconv.FormatFloat(v, 'f', digits, 64)) + suffix
+}
+
+// trimDecimals removes the trailing zeros of a formatted decimal number
+func trimDecimals(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
@@ -1,3 +1,5 @@
 module github.com/aorith/svg-timeline
 
 go 1.25.3
+
+require golang.org/x/text v0.36.0
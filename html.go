@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: MIT
+
+package svgtimeline
+
+import (
+	"html"
+	"strings"
+)
+
+// htmlPageStyle centers the timeline in the generated HTML page
+const htmlPageStyle = `body {
+  margin: 0;
+  min-height: 100vh;
+  display: flex;
+  align-items: center;
+  justify-content: center;
+}
+
+body > svg {
+  max-width: 95vw;
+}`
+
+// GenerateHTML generates a standalone HTML document embedding the timeline SVG
+func (t *Timeline) GenerateHTML(title string) (string, error) {
+	svg, err := t.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	sb.WriteString("<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	if t.styleHref != "" {
+		sb.WriteString("<link rel=\"stylesheet\" href=\"" + html.EscapeString(t.styleHref) + "\">\n")
+	}
+	sb.WriteString("<style>\n" + htmlPageStyle + "\n</style>\n")
+	sb.WriteString("</head>\n<body>\n")
+	sb.WriteString(svg)
+	sb.WriteString("\n</body>\n</html>\n")
+	return sb.String(), nil
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: MIT
+
+package svgtimeline
+
+import (
+	"encoding/json"
+)
+
+// Layout is the geometry of a timeline as computed by Generate, in SVG viewBox units
+type Layout struct {
+	Width  float64       `json:"width"`
+	Height float64       `json:"height"`
+	Ticks  []Tick        `json:"ticks"`
+	Events []EventLayout `json:"events"`
+}
+
+// EventLayout is the rectangle drawn for an event
+//
+// Clipped events report their visible part only and eras span all the rows they cover.
+type EventLayout struct {
+	ID     string  `json:"id,omitempty"`
+	Class  string  `json:"class"` // classes of the event group, including the "tl-event" or "tl-era" base class
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Label  string  `json:"label,omitempty"`
+}
+
+// Layout returns the geometry of the canvas, the axis ticks and the drawn events
+func (t *Timeline) Layout() (Layout, error) {
+	l, err := t.setup()
+	if err != nil {
+		return Layout{}, err
+	}
+	t.drawRows(l)
+	width, height := l.viewBoxSize()
+	return Layout{
+		Width:  width,
+		Height: height,
+		Ticks:  t.ticks(l),
+		Events: l.events,
+	}, nil
+}
+
+// GenerateJSON generates a JSON document describing the geometry of the timeline
+//
+// It is the Layout encoded as JSON, useful to re-render the timeline with other tools.
+func (t *Timeline) GenerateJSON() ([]byte, error) {
+	layout, err := t.Layout()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(layout)
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: MIT
+
+package svgtimeline
+
+import (
+	"encoding/xml"
+	"io"
+	"slices"
+	"strings"
+)
+
+// NormalizeSVG returns a canonical form of an SVG document to compare outputs in tests
+//
+// Attributes are sorted by name, runs of whitespace in text are collapsed, comments
+// are dropped and every element is written on its own indented line. Two documents
+// with the same normalized form render the same. Input that is not well-formed XML
+// is returned unchanged.
+func NormalizeSVG(s string) string {
+	decoder := xml.NewDecoder(strings.NewReader(s))
+	var sb strings.Builder
+	depth := 0
+	inline := false // the last token written was a start element or text of the open element
+	for {
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return s
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			newLine(&sb, depth)
+			sb.WriteString("<" + qualifiedName(tok.Name))
+			attrs := slices.Clone(tok.Attr)
+			slices.SortFunc(attrs, func(a, b xml.Attr) int {
+				return strings.Compare(qualifiedName(a.Name), qualifiedName(b.Name))
+			})
+			for _, attr := range attrs {
+				sb.WriteString(" " + qualifiedName(attr.Name) + `="`)
+				_ = xml.EscapeText(&sb, []byte(attr.Value))
+				sb.WriteString(`"`)
+			}
+			sb.WriteString(">")
+			depth++
+			inline = true
+		case xml.EndElement:
+			depth--
+			if !inline {
+				newLine(&sb, depth)
+			}
+			sb.WriteString("</" + qualifiedName(tok.Name) + ">")
+			inline = false
+		case xml.CharData:
+			text := strings.Join(strings.Fields(string(tok)), " ")
+			if text == "" {
+				continue
+			}
+			if !inline {
+				newLine(&sb, depth)
+			}
+			_ = xml.EscapeText(&sb, []byte(text))
+		case xml.ProcInst:
+			newLine(&sb, depth)
+			sb.WriteString("<?" + tok.Target + " " + strings.TrimSpace(string(tok.Inst)) + "?>")
+			inline = false
+		case xml.Directive:
+			newLine(&sb, depth)
+			sb.WriteString("<!" + string(tok) + ">")
+			inline = false
+		}
+	}
+	return strings.TrimPrefix(sb.String(), "\n")
+}
+
+// newLine starts a new line indented to the depth
+func newLine(sb *strings.Builder, depth int) {
+	sb.WriteString("\n" + strings.Repeat("  ", depth))
+}
+
+// qualifiedName returns the name with its namespace prefix as written in the document
+func qualifiedName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
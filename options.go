@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: MIT
+
+package svgtimeline
+
+// Option configures a Timeline created with NewTimelineWithOptions
+type Option func(*Timeline)
+
+// NewTimelineWithOptions creates a new timeline with default config and applies the given options
+func NewTimelineWithOptions(opts ...Option) *Timeline {
+	t := NewTimeline()
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// WithID sets the unique HTML identifier of the timeline SVG
+func WithID(id string) Option {
+	return func(t *Timeline) {
+		t.SetID(id)
+	}
+}
+
+// WithPrecision sets the precision of the timeline
+func WithPrecision(p int) Option {
+	return func(t *Timeline) {
+		t.SetPrecision(p)
+	}
+}
+
+// WithWidth sets the SVG width
+func WithWidth(width string) Option {
+	return func(t *Timeline) {
+		t.SetWidth(width)
+	}
+}
+
+// WithHeight sets the SVG height
+func WithHeight(height string) Option {
+	return func(t *Timeline) {
+		t.SetHeight(height)
+	}
+}
+
+// WithNumTicks sets the number of ticks for the timeline
+func WithNumTicks(n int) Option {
+	return func(t *Timeline) {
+		t.SetNumTicks(n)
+	}
+}
+
+// WithTickHeight sets the height of the timeline ticks
+func WithTickHeight(h int) Option {
+	return func(t *Timeline) {
+		t.SetTickHeight(h)
+	}
+}
+
+// WithMargins sets the margins of the timeline inside of the SVG
+func WithMargins(top, right, bottom, left int) Option {
+	return func(t *Timeline) {
+		t.SetMargins(top, right, bottom, left)
+	}
+}
+
+// WithStyle sets the CSS style for the timeline
+func WithStyle(s string) Option {
+	return func(t *Timeline) {
+		t.SetStyle(s)
+	}
+}
@@ -6,14 +6,87 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"iter"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// eventShapes maps the CFG names of the event shapes
+var eventShapes = map[string]EventShape{
+	"rect":    ShapeRect,
+	"rounded": ShapeRounded,
+	"chevron": ShapeChevron,
+}
+
+// cfgState holds the @timeline settings that also apply to the later files parsed into the same timeline
+type cfgState struct {
+	baseTime   time.Time // base of the event times given as offsets such as +3s
+	timeFormat string    // layout tried before the common formats when parsing times
+}
+
 // GenerateFromCFG generates the timeline by parsing a config file with an optional css style
 func GenerateFromCFG(filename string, cssFilename string) (string, error) {
+	tl, err := NewTimelineFromCFG(filename, cssFilename)
+	if err != nil {
+		return "", err
+	}
+	return tl.Generate()
+}
+
+// GenerateFromCFGOptions configures how config files are parsed by GenerateFromCFGWithOptions
+type GenerateFromCFGOptions struct {
+	Lenient bool // report unknown sections and properties as warnings instead of failing
+}
+
+// GenerateFromCFGWithOptions generates the timeline by parsing a config file with an optional css style
+//
+// The warnings collected while parsing in lenient mode are returned alongside the SVG.
+func GenerateFromCFGWithOptions(filename string, cssFilename string, opts GenerateFromCFGOptions) (string, []string, error) {
+	tl, warnings, err := parseCFGFile(filename, cssFilename, opts, &cfgState{})
+	if err != nil {
+		return "", warnings, err
+	}
+	svg, err := tl.Generate()
+	return svg, warnings, err
+}
+
+// NewTimelineFromCFG creates a timeline by parsing a config file with an optional css style
+func NewTimelineFromCFG(filename string, cssFilename string) (*Timeline, error) {
+	tl, _, err := parseCFGFile(filename, cssFilename, GenerateFromCFGOptions{}, &cfgState{})
+	return tl, err
+}
+
+// GenerateFromCFGFiles generates a single timeline by parsing several config files with an optional css style
+//
+// The rows of every file are appended in order. Only the first file may define
+// the @timeline section, a @timeline section in any later file is an error. Its
+// base_time and time_format also apply to the times of the later files.
+func GenerateFromCFGFiles(cfgFiles []string, cssFilename string) (string, error) {
+	if len(cfgFiles) == 0 {
+		return "", fmt.Errorf("no config files given")
+	}
+	state := &cfgState{}
+	tl, _, err := parseCFGFile(cfgFiles[0], cssFilename, GenerateFromCFGOptions{}, state)
+	if err != nil {
+		return "", err
+	}
+	for _, filename := range cfgFiles[1:] {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return "", fmt.Errorf("error reading file '%s': %v", filename, err)
+		}
+		if _, err := parseCFG(tl, bytes.NewReader(data), GenerateFromCFGOptions{}, state, false, appendRow(tl)); err != nil {
+			return "", fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	return tl.Generate()
+}
+
+// GenerateFromCFGReader generates the timeline by parsing the config read from r with an optional css style
+func GenerateFromCFGReader(r io.Reader, cssFilename string) (string, error) {
 	var cssStyle string
 	if cssFilename != "" {
 		css, err := os.ReadFile(cssFilename)
@@ -23,25 +96,101 @@ func GenerateFromCFG(filename string, cssFilename string) (string, error) {
 		cssStyle = string(css)
 	}
 
+	tl := NewTimeline()
+	if _, err := parseCFG(tl, r, GenerateFromCFGOptions{}, &cfgState{}, true, appendRow(tl)); err != nil {
+		return "", err
+	}
+	if cssStyle != "" {
+		tl.SetStyle(cssStyle)
+	}
+	return tl.Generate()
+}
+
+// ParseCFG parses the config read from r yielding each row as soon as all of its events are parsed
+//
+// Parsing stops at the first error, which is yielded with a nil row. The @timeline
+// section is validated but its settings are discarded, use GenerateFromCFGReader
+// to apply them. Rows can be added to a timeline with Timeline.AppendRow.
+func ParseCFG(r io.Reader) iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		stopped := false
+		_, err := parseCFG(NewTimeline(), r, GenerateFromCFGOptions{}, &cfgState{}, true, func(row *Row) bool {
+			stopped = !yield(row, nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
+
+// appendRow returns a parseCFG callback appending the rows to the timeline
+func appendRow(tl *Timeline) func(*Row) bool {
+	return func(row *Row) bool {
+		tl.AppendRow(row)
+		return true
+	}
+}
+
+// parseCFGFile creates a timeline by parsing a config file returning the warnings of lenient mode
+func parseCFGFile(filename string, cssFilename string, opts GenerateFromCFGOptions, state *cfgState) (*Timeline, []string, error) {
+	var cssStyle string
+	if cssFilename != "" {
+		css, err := os.ReadFile(cssFilename)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading file '%s': %v", cssFilename, err)
+		}
+		cssStyle = string(css)
+	}
+
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		return "", fmt.Errorf("error reading file '%s': %v", filename, err)
+		return nil, nil, fmt.Errorf("error reading file '%s': %v", filename, err)
 	}
 
-	r := bytes.NewReader(data)
-	scanner := bufio.NewScanner(r)
-
 	// Initialize the timeline
 	tl := NewTimeline()
+	warnings, err := parseCFG(tl, bytes.NewReader(data), opts, state, true, appendRow(tl))
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	if cssStyle != "" {
+		tl.SetStyle(cssStyle)
+	}
 
-	margins := [4]int{0, 0, 0, 0} // top , right , bottom , left
-	setMargins := false
+	return tl, warnings, nil
+}
+
+// parseCFG parses the config applying the @timeline section to the timeline and returns the warnings of lenient mode
+//
+// Each completed row is passed to onRow, parsing stops early when it returns false.
+// The @timeline section is rejected unless allowTimeline is set, its base_time and
+// time_format are kept in state for the files parsed after it.
+func parseCFG(tl *Timeline, r io.Reader, opts GenerateFromCFGOptions, state *cfgState, allowTimeline bool, onRow func(*Row) bool) ([]string, error) {
+	var warnings []string
+	// unknown downgrades errors about unknown keys to warnings in lenient mode
+	unknown := func(err error) error {
+		if !opts.Lenient {
+			return err
+		}
+		warnings = append(warnings, err.Error())
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+
+	var currentRow *Row
 	var currentEvent *Event
+	var eventLineNum, eventCol int // position of the section header of the current event
+	var eventLine string
 
 	currentSection := ""
 	lineNum := 0
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		raw := stripComment(scanner.Text())
+		line := strings.TrimSpace(raw)
+		col := len(raw) - len(strings.TrimLeft(raw, " \t")) + 1 // column where the trimmed line starts
 		lineNum++
 
 		// Skip empty lines and comments
@@ -53,33 +202,48 @@ func GenerateFromCFG(filename string, cssFilename string) (string, error) {
 		switch line[0] {
 		case '@':
 			if currentEvent != nil {
-				row := tl.GetLastRow()
-				if row == nil {
-					return "", fmt.Errorf("error at line %d, cannot add an event without creating a row first", lineNum)
+				if currentRow == nil {
+					return warnings, cfgError(lineNum, col, line, "cannot add an event without creating a row first")
 				}
-				row.AddEvent(*currentEvent)
+				currentRow.AddEvent(*currentEvent)
 				currentEvent = nil
 			}
 
 			currentSection = parts[0] // @timeline, @row, @task, @era
+			if currentSection == "@timeline" && !allowTimeline {
+				return warnings, cfgError(lineNum, col, line, "only the first config file may define the @timeline section")
+			}
 			switch currentSection {
 			case "@row":
-				height := parseIntDefault(parts, 1, 30)
+				if currentRow != nil && !onRow(currentRow) {
+					return warnings, nil
+				}
+				height := parseIntDefault(parts, 1, 30) // 0 sizes the row to fit its labels
 				separator := parseIntDefault(parts, 2, 5)
-				tl.AddRow(height, separator)
+				currentRow = &Row{height: height, separatorHeight: separator, events: make([]Event, 0)}
 			case "@era":
 				currentEvent = &Event{Type: EventTypeEra}
 			case "@task":
 				currentEvent = &Event{Type: EventTypeTask}
 			}
+			if currentEvent != nil {
+				eventLineNum, eventCol, eventLine = lineNum, col, line
+			}
 
 		default:
 			key, val, ok := strings.Cut(line, "=")
-			if ok {
-				key = strings.TrimSpace(key)
-				val = strings.TrimSpace(val)
-			} else {
-				return "", fmt.Errorf("unknown value at line %d", lineNum)
+			if !ok {
+				return warnings, cfgError(lineNum, col, line, "expected 'key = value'")
+			}
+			valCol := col + len(key) + 1 + len(val) - len(strings.TrimLeft(val, " \t")) // column where the value starts
+			key = strings.TrimSpace(key)
+			val = strings.TrimSpace(val)
+			if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+				unquoted, err2 := strconv.Unquote(val)
+				if err2 != nil {
+					return warnings, cfgError(lineNum, valCol, line, "invalid quoted value: %v", err2)
+				}
+				val = unquoted
 			}
 
 			switch currentSection {
@@ -90,7 +254,7 @@ func GenerateFromCFG(filename string, cssFilename string) (string, error) {
 				case "precision", "num_ticks", "tick_height", "margin_top", "margin_bottom", "margin_left", "margin_right":
 					x, err2 := strconv.Atoi(val)
 					if err2 != nil {
-						return "", fmt.Errorf("error at line %d: %v", lineNum, err2)
+						return warnings, cfgError(lineNum, valCol, line, "invalid integer for '%s': %v", key, err2)
 					}
 
 					switch key {
@@ -101,32 +265,54 @@ func GenerateFromCFG(filename string, cssFilename string) (string, error) {
 					case "tick_height":
 						tl.SetTickHeight(x)
 					case "margin_top":
-						setMargins = true
-						margins[0] = x
+						tl.SetMarginTop(x)
 					case "margin_right":
-						setMargins = true
-						margins[1] = x
+						tl.SetMarginRight(x)
 					case "margin_bottom":
-						setMargins = true
-						margins[2] = x
+						tl.SetMarginBottom(x)
 					case "margin_left":
-						setMargins = true
-						margins[3] = x
+						tl.SetMarginLeft(x)
 					}
 
 				case "id":
 					tl.SetID(val)
+				case "time_format":
+					state.timeFormat = val
+				case "base_time":
+					t, err2 := parseTime(val, state.timeFormat)
+					if err2 != nil {
+						return warnings, cfgError(lineNum, valCol, line, "%v", err2)
+					}
+					state.baseTime = t
 				case "width":
 					tl.SetWidth(val)
 				case "height":
 					tl.SetHeight(val)
 
 				default:
-					return "", fmt.Errorf("unknown property '%s' at line %d", key, lineNum)
+					if err := unknown(cfgError(lineNum, col, line, "unknown property '%s'", key)); err != nil {
+						return warnings, err
+					}
 				}
 
 			case "@row":
-				return "", fmt.Errorf("error at line %d, row has no configuration options", lineNum)
+				switch key {
+				case "height", "separator":
+					x, err2 := strconv.Atoi(val)
+					if err2 != nil {
+						return warnings, cfgError(lineNum, valCol, line, "invalid integer for '%s': %v", key, err2)
+					}
+					if key == "height" {
+						currentRow.height = x
+					} else {
+						currentRow.separatorHeight = x
+					}
+
+				default:
+					if err := unknown(cfgError(lineNum, col, line, "unknown row property '%s'", key)); err != nil {
+						return warnings, err
+					}
+				}
 
 			case "@task", "@era":
 				switch key {
@@ -142,52 +328,129 @@ func GenerateFromCFG(filename string, cssFilename string) (string, error) {
 				case "title":
 					currentEvent.Title = val
 
+				case "url":
+					currentEvent.URL = val
+
+				case "shape":
+					shape, ok := eventShapes[val]
+					if !ok {
+						return warnings, cfgError(lineNum, valCol, line, "unknown shape '%s'", val)
+					}
+					currentEvent.Shape = shape
+
+				case "pattern":
+					if _, ok := patternIDs[val]; !ok {
+						return warnings, cfgError(lineNum, valCol, line, "unknown pattern '%s'", val)
+					}
+					currentEvent.Pattern = val
+
+				case "outline":
+					outline, err2 := strconv.ParseBool(val)
+					if err2 != nil {
+						return warnings, cfgError(lineNum, valCol, line, "invalid outline: %v", err2)
+					}
+					currentEvent.Outline = outline
+
+				case "opacity":
+					opacity, err2 := strconv.ParseFloat(val, 64)
+					if err2 != nil {
+						return warnings, cfgError(lineNum, valCol, line, "invalid opacity: %v", err2)
+					}
+					if opacity < 0 || opacity > 1 {
+						return warnings, cfgError(lineNum, valCol, line, "opacity must be between 0 and 1, got %v", opacity)
+					}
+					currentEvent.Opacity = opacity
+
 				case "duration":
-					dur, err2 := time.ParseDuration(val)
+					dur, err2 := parseDuration(val)
 					if err2 != nil {
-						return "", fmt.Errorf("error at line %d while parsing duration of event, %v", lineNum, err2)
+						return warnings, cfgError(lineNum, valCol, line, "invalid duration: %v", err2)
 					}
 					currentEvent.Duration = dur
 
 				case "time":
-					t, err2 := parseTime(val)
+					if val != "" && (val[0] == '+' || val[0] == '-') {
+						offset, err2 := parseDuration(val)
+						if err2 != nil {
+							return warnings, cfgError(lineNum, valCol, line, "invalid time offset: %v", err2)
+						}
+						if state.baseTime.IsZero() {
+							return warnings, cfgError(lineNum, valCol, line, "time offsets require 'base_time' in the @timeline section")
+						}
+						currentEvent.Time = state.baseTime.Add(offset)
+						break
+					}
+					t, err2 := parseTime(val, state.timeFormat)
 					if err2 != nil {
-						return "", err2
+						return warnings, cfgError(lineNum, valCol, line, "%v", err2)
 					}
 					currentEvent.Time = t
 
 				default:
-					return "", fmt.Errorf("unknown event property '%s' at line %d", key, lineNum)
+					if err := unknown(cfgError(lineNum, col, line, "unknown event property '%s'", key)); err != nil {
+						return warnings, err
+					}
 				}
 
 			default:
-				return "", fmt.Errorf("unknown section: %s", currentSection)
+				if err := unknown(cfgError(lineNum, col, line, "unknown section '%s'", currentSection)); err != nil {
+					return warnings, err
+				}
 			}
 		}
 
 	}
 
-	if err = scanner.Err(); err != nil {
-		return "", fmt.Errorf("scanner error: %v", err)
+	if err := scanner.Err(); err != nil {
+		return warnings, fmt.Errorf("scanner error: %v", err)
 	}
 
-	// Last event
-	row := tl.GetLastRow()
-	if row == nil {
-		return "", fmt.Errorf("error at line %d, cannot add an event without creating a row first", lineNum)
+	// Last event and row
+	if currentEvent != nil {
+		if currentRow == nil {
+			return warnings, cfgError(eventLineNum, eventCol, eventLine, "cannot add an event without creating a row first")
+		}
+		currentRow.AddEvent(*currentEvent)
+		currentEvent = nil
 	}
-	row.AddEvent(*currentEvent)
-	currentEvent = nil
-
-	if setMargins {
-		tl.SetMargins(margins[0], margins[1], margins[2], margins[3])
+	if currentRow != nil && !onRow(currentRow) {
+		return warnings, nil
 	}
 
-	if cssStyle != "" {
-		tl.SetStyle(cssStyle)
-	}
+	return warnings, nil
+}
 
-	return tl.Generate()
+// cfgError returns a config error pointing at the line and column showing the offending line
+func cfgError(lineNum, col int, line string, format string, args ...any) error {
+	return fmt.Errorf("line %d, col %d: %s, got %q", lineNum, col, fmt.Sprintf(format, args...), line)
+}
+
+// stripComment removes a trailing comment from a config line
+//
+// A comment starts with a '#' surrounded by whitespace (or at the start/end of the line)
+// outside of double quotes, so values like "Step #1" are preserved.
+func stripComment(line string) string {
+	inQuotes := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inQuotes {
+				i++ // skip the escaped character
+			}
+		case '"':
+			inQuotes = !inQuotes
+		case '#':
+			if inQuotes {
+				continue
+			}
+			before := i == 0 || line[i-1] == ' ' || line[i-1] == '\t'
+			after := i == len(line)-1 || line[i+1] == ' ' || line[i+1] == '\t'
+			if before && after {
+				return line[:i]
+			}
+		}
+	}
+	return line
 }
 
 // parseIntDefault is a helper function to convert a string to int
@@ -203,8 +466,65 @@ func parseIntDefault(parts []string, i, def int) int {
 	return n
 }
 
-// parseTime tries to parse time strings in common formats
-func parseTime(input string) (time.Time, error) {
+// parseDuration parses a duration like time.ParseDuration, also accepting days ("d") and weeks ("w")
+func parseDuration(input string) (time.Duration, error) {
+	s, sign := input, ""
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		s, sign = s[1:], s[:1]
+	}
+
+	// Split off the days and weeks, the remaining units are left to time.ParseDuration
+	var days float64
+	var hasDays bool
+	var rest strings.Builder
+	for s != "" {
+		n := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if n < 0 {
+			n = len(s)
+		}
+		u := n + strings.IndexFunc(s[n:], func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' })
+		if u < n {
+			u = len(s)
+		}
+		switch unit := s[n:u]; unit {
+		case "d", "w":
+			v, err := strconv.ParseFloat(s[:n], 64)
+			if err != nil {
+				return 0, fmt.Errorf("time: invalid duration %q", input)
+			}
+			if unit == "w" {
+				v *= 7
+			}
+			days += v
+			hasDays = true
+		default:
+			rest.WriteString(s[:u])
+		}
+		s = s[u:]
+	}
+
+	var d time.Duration
+	if rest.Len() > 0 || !hasDays {
+		var err error
+		if d, err = time.ParseDuration(rest.String()); err != nil {
+			return 0, fmt.Errorf("time: invalid duration %q", input)
+		}
+	}
+	d += time.Duration(days * float64(24*time.Hour))
+	if sign == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// parseTime tries to parse time strings with the given layout, when not empty, and then in common formats
+func parseTime(input, layout string) (time.Time, error) {
+	if layout != "" {
+		if t, err := time.Parse(layout, input); err == nil {
+			return t, nil
+		}
+	}
+
 	formats := []string{
 		"2006-01-02T15:04:05.99Z", // UTC with nanosecond precision
 		time.UnixDate,             // Mon Jan _2 15:04:05 MST 2006
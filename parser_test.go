@@ -0,0 +1,347 @@
+// SPDX-License-Identifier: MIT
+
+package svgtimeline_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	svgtimeline "github.com/aorith/svg-timeline"
+)
+
+// generateFromString writes the config to a temporary file and generates its SVG
+func generateFromString(t *testing.T, cfg string) (string, error) {
+	t.Helper()
+	fn := filepath.Join(t.TempDir(), "timeline.cfg")
+	if err := os.WriteFile(fn, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return svgtimeline.GenerateFromCFG(fn, "")
+}
+
+func TestTrailingComments(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  string
+		want string
+	}{
+		{
+			name: "Unquoted value with trailing comment",
+			cfg:  "@row 30 5\n@task\ntext = fetch  # request phase\nduration = 10s  # request phase\n",
+			want: ">fetch</text>",
+		},
+		{
+			name: "Unquoted value containing a hash",
+			cfg:  "@row 30 5\n@task\ntext = Step #1\nduration = 10s\n",
+			want: ">Step #1</text>",
+		},
+		{
+			name: "Quoted value containing a comment marker",
+			cfg:  "@row 30 5\n@task\ntext = \"Step # 1\" # comment\nduration = 10s\n",
+			want: ">Step # 1</text>",
+		},
+		{
+			name: "Quoted value with escaped quotes",
+			cfg:  "@row 30 5\n@task\ntext = \"say \\\"# hi\\\"\"\nduration = 10s # comment\n",
+			want: ">say &#34;# hi&#34;</text>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg, err := generateFromString(t, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(svg, tt.want) {
+				t.Errorf("output does not contain %q:\n%s", tt.want, svg)
+			}
+		})
+	}
+}
+
+func TestLenientMode(t *testing.T) {
+	cfg := "@timeline\nfuture_option = 1\n@row 30 5\n@task\ntext = fetch\nduration = 10s\ncolor = red\n"
+	fn := filepath.Join(t.TempDir(), "timeline.cfg")
+	if err := os.WriteFile(fn, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := svgtimeline.GenerateFromCFGWithOptions(fn, "", svgtimeline.GenerateFromCFGOptions{}); err == nil {
+		t.Errorf("expected an error for unknown keys in strict mode")
+	}
+
+	svg, warnings, err := svgtimeline.GenerateFromCFGWithOptions(fn, "", svgtimeline.GenerateFromCFGOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, ">fetch</text>") {
+		t.Errorf("event missing from the output:\n%s", svg)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("got %d warnings, want 2: %q", len(warnings), warnings)
+	}
+	for i, want := range []string{"future_option", "color"} {
+		if !strings.Contains(warnings[i], want) {
+			t.Errorf("warning %q does not mention %q", warnings[i], want)
+		}
+	}
+}
+
+func TestGenerateFromCFGFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, cfg string) string {
+		fn := filepath.Join(dir, name)
+		if err := os.WriteFile(fn, []byte(cfg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return fn
+	}
+	first := write("first.cfg", "@timeline\nid = merged\n@row 30 5\n@task\ntext = api\nduration = 10s\n")
+	second := write("second.cfg", "@row 30 5\n@task\ntext = db\nduration = 5s\n")
+	redefined := write("redefined.cfg", "@timeline\nid = other\n@row 30 5\n")
+
+	svg, err := svgtimeline.GenerateFromCFGFiles([]string{first, second}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, db := strings.Index(svg, ">api</text>"), strings.Index(svg, ">db</text>")
+	if api < 0 || db < 0 || db < api {
+		t.Errorf("events of both files are not rendered in order:\n%s", svg)
+	}
+	if !strings.Contains(svg, `id="merged"`) {
+		t.Errorf("@timeline section of the first file not applied:\n%s", svg)
+	}
+
+	if _, err := svgtimeline.GenerateFromCFGFiles([]string{first, redefined}, ""); err == nil {
+		t.Errorf("expected an error redefining @timeline in a later file")
+	}
+
+	// The base_time and time_format of the first file apply to the later ones
+	base := write("base.cfg", "@timeline\nbase_time = 2025-11-01T12:20:50Z\ntime_format = 02/01/2006 15:04:05\n@row 30 5\n@task\ntext = api\nduration = 2s\ntime = 01/11/2025 12:20:50\n")
+	offsets := write("offsets.cfg", "@row 30 5\n@task\ntext = db\nduration = 2s\ntime = +2s\n@task\ntext = cache\nduration = 1s\ntime = 01/11/2025 12:20:55\n")
+	absolute := write("absolute.cfg", "@timeline\n@row 30 5\n@task\ntext = api\nduration = 2s\ntime = 2025-11-01T12:20:50Z\n@row 30 5\n@task\ntext = db\nduration = 2s\ntime = 2025-11-01T12:20:52Z\n@task\ntext = cache\nduration = 1s\ntime = 2025-11-01T12:20:55Z\n")
+	got, err := svgtimeline.GenerateFromCFGFiles([]string{base, offsets}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := svgtimeline.GenerateFromCFG(absolute, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("times of the later file differ from the absolute ones:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTimeOffsets(t *testing.T) {
+	absolute := "@row 30 5\n@task\nid = a\nduration = 2s\ntime = 2025-11-01T12:20:53Z\n@task\nid = b\nduration = 1s\ntime = 2025-11-01T12:20:50Z\n"
+	want, err := generateFromString(t, "@timeline\n"+absolute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offsets := "@row 30 5\n@task\nid = a\nduration = 2s\ntime = +3s\n@task\nid = b\nduration = 1s\ntime = 2025-11-01T12:20:50Z\n"
+	got, err := generateFromString(t, "@timeline\nbase_time = 2025-11-01T12:20:50Z\n"+offsets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("offset times differ from the absolute ones:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := generateFromString(t, offsets); err == nil || !strings.Contains(err.Error(), "base_time") {
+		t.Errorf("expected an error about the missing base_time, got %v", err)
+	}
+}
+
+func TestParseCFG(t *testing.T) {
+	cfg := "@timeline\nid = stream\n@row 30 5\n@task\ntext = a\nduration = 1s\n@task\ntext = b\nduration = 1s\n@row 20 0\n@era\ntext = c\nduration = 2s\n"
+	var counts []int
+	for row, err := range svgtimeline.ParseCFG(strings.NewReader(cfg)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts = append(counts, row.EventCount())
+	}
+	if len(counts) != 2 || counts[0] != 2 || counts[1] != 1 {
+		t.Errorf("got rows with %v events, want [2 1]", counts)
+	}
+
+	for range svgtimeline.ParseCFG(strings.NewReader(cfg)) {
+		break // stopping early must not panic
+	}
+
+	var gotErr error
+	for row, err := range svgtimeline.ParseCFG(strings.NewReader(cfg + "@task\nduration = soon\n")) {
+		if err != nil {
+			gotErr = err
+			if row != nil {
+				t.Errorf("row yielded along with the error")
+			}
+		}
+	}
+	if gotErr == nil || !strings.Contains(gotErr.Error(), "line 15") {
+		t.Errorf("expected an error at line 15, got %v", gotErr)
+	}
+
+	want, err := generateFromString(t, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := svgtimeline.GenerateFromCFGReader(strings.NewReader(cfg), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("GenerateFromCFGReader output differs from GenerateFromCFG:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRowKeys(t *testing.T) {
+	events := "@task\ntext = a\nduration = 1s\n"
+	want, err := generateFromString(t, "@row 40 10\n"+events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generateFromString(t, "@row\nheight = 40\nseparator = 10\n"+events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("keyed row differs from the positional one:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	for _, cfg := range []string{"@row\nheight = tall\n", "@row\ncolor = red\n"} {
+		if _, err := generateFromString(t, cfg+events); err == nil {
+			t.Errorf("expected an error for %q", cfg)
+		}
+	}
+}
+
+func TestOutlineKey(t *testing.T) {
+	svg, err := generateFromString(t, "@row 30 5\n@task\nid = planned\noutline = true\nduration = 10s\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, `class="tl-event tl-outline"`) {
+		t.Errorf("outline not applied:\n%s", svg)
+	}
+	if _, err := generateFromString(t, "@row 30 5\n@task\noutline = maybe\nduration = 10s\n"); err == nil {
+		t.Errorf("expected an error for an invalid outline value")
+	}
+}
+
+func TestPatternKey(t *testing.T) {
+	svg, err := generateFromString(t, "@row 30 5\n@task\npattern = dots\nduration = 10s\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, `style="fill: url(#tl-pattern-dots)"`) {
+		t.Errorf("pattern not applied:\n%s", svg)
+	}
+	_, err = generateFromString(t, "@row 30 5\n@task\npattern = stripes\nduration = 10s\n")
+	if want := "line 3, col 11: unknown pattern 'stripes'"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want it to contain %q", err, want)
+	}
+}
+
+func TestSingleMarginKey(t *testing.T) {
+	svg, err := generateFromString(t, "@timeline\nmargin_left = 50\n@row 30 5\n@task\nduration = 10s\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The other margins keep their defaults
+	if !strings.Contains(svg, `viewBox="0 0 1080.000000 85.000000"`) {
+		t.Errorf("unexpected dimensions:\n%s", svg)
+	}
+}
+
+func TestOpacityKey(t *testing.T) {
+	svg, err := generateFromString(t, "@row 30 5\n@task\nopacity = 0.25\nduration = 10s\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, `fill-opacity="0.25"`) {
+		t.Errorf("opacity not applied:\n%s", svg)
+	}
+	for _, val := range []string{"half", "2"} {
+		if _, err := generateFromString(t, "@row 30 5\n@task\nopacity = "+val+"\nduration = 10s\n"); err == nil {
+			t.Errorf("expected an error for the opacity %q", val)
+		}
+	}
+}
+
+func TestDayWeekDurations(t *testing.T) {
+	for val, want := range map[string]time.Duration{
+		"3d":     3 * 24 * time.Hour,
+		"1w":     7 * 24 * time.Hour,
+		"1w2d3h": 9*24*time.Hour + 3*time.Hour,
+		"1.5d":   36 * time.Hour,
+		"90m":    90 * time.Minute,
+	} {
+		var got []time.Duration
+		for row, err := range svgtimeline.ParseCFG(strings.NewReader("@row 30 5\n@task\nduration = " + val + "\n")) {
+			if err != nil {
+				t.Fatalf("%s: %v", val, err)
+			}
+			for _, e := range row.GetEvents() {
+				got = append(got, e.Duration)
+			}
+		}
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("%s: got durations %v, want %v", val, got, want)
+		}
+	}
+
+	for _, val := range []string{"d", "2x", "1d2", ""} {
+		if _, err := generateFromString(t, "@row 30 5\n@task\nduration = "+val+"\n"); err == nil {
+			t.Errorf("expected an error for the duration %q", val)
+		}
+	}
+}
+
+func TestTimeFormatKey(t *testing.T) {
+	want, err := generateFromString(t, "@row 30 5\n@task\nduration = 2s\ntime = 2025-11-01T12:20:53Z\n@task\nduration = 1s\ntime = 2025-11-01T12:20:50Z\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	custom := "@row 30 5\n@task\nduration = 2s\ntime = 01.11.2025 12h20m53s\n@task\nduration = 1s\ntime = 2025-11-01T12:20:50Z\n"
+	got, err := generateFromString(t, "@timeline\ntime_format = 02.01.2006 15h04m05s\n"+custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("custom format times differ from the RFC 3339 ones:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := generateFromString(t, custom); err == nil {
+		t.Error("expected an error for the custom format without time_format")
+	}
+}
+
+func TestCFGErrorColumns(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  string
+		want string
+	}{
+		{"missing equals", "@row 30 5\n@task\n  duration 10s\n", `line 3, col 3: expected 'key = value', got "duration 10s"`},
+		{"invalid value", "@row 30 5\n@task\nduration =  soon\n", `line 3, col 13: invalid duration`},
+		{"unknown key", "@row 30 5\n@task\n\tcolour = red\n", `line 3, col 2: unknown event property 'colour'`},
+		{"event without row at the end", "@timeline\n  @task\nduration = 1s\n", `line 2, col 3: cannot add an event without creating a row first, got "@task"`},
+		{"event without row", "@task\nduration = 1s\n@row 30 5\n", `line 3, col 1: cannot add an event without creating a row first`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := generateFromString(t, tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -3,19 +3,103 @@
 package svgtimeline
 
 import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
 	"encoding/xml"
 	"fmt"
+	"io"
+	"maps"
 	"math"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	_ "embed"
+
+	"golang.org/x/text/language"
 )
 
 //go:embed default.css
 var DefaultStyle string
 
+//go:embed defs.xml
+var patternDefs string
+
+//go:embed tooltip.js
+var tooltipScript string
+
+// textWidthFactor approximates the width of a monospace glyph relative to its font size
+const textWidthFactor = 0.7
+
+// tickFontSize is the font size of the tick labels
+const tickFontSize = 12
+
+// labelLineHeight is the space taken below the axis by each line of labels
+const labelLineHeight = 15
+
+// autoRowFontSize is the label font size that auto-sized rows are able to fit
+const autoRowFontSize = 12
+
+// patternIDs maps the supported Event.Pattern names to their <pattern> ids in defs.xml
+var patternIDs = map[string]string{
+	"hatch": "tl-pattern-hatch",
+	"dots":  "tl-pattern-dots",
+}
+
+// labelBaselines maps the dominant baselines accepted by SetLabelBaseline to the
+// vertical anchor of the label in its row: -1 for the top, 0 for the middle and 1 for the bottom
+var labelBaselines = map[string]int{
+	"middle":      0,
+	"central":     0,
+	"hanging":     -1,
+	"text-top":    -1,
+	"auto":        1,
+	"alphabetic":  1,
+	"ideographic": 1,
+	"text-bottom": 1,
+}
+
+// titleFontSize is the font size of the chart title
+const titleFontSize = 16
+
+// titleHeight is the space taken by the chart title above the rows
+const titleHeight = titleFontSize + 12
+
+// leaderFontSize is the font size of the labels drawn in the leader track
+const leaderFontSize = 10
+
+// leaderLaneHeight is the height of each lane of stacked labels in the leader track
+const leaderLaneHeight = leaderFontSize + 4
+
+// leaderGap is the space between the leader track and the first row
+const leaderGap = 8
+
+// svgBytesPerEvent estimates the size of the markup of an event to preallocate the output
+const svgBytesPerEvent = 256
+
+// annotationFontSize is the maximum font size of the annotation labels
+const annotationFontSize = 10
+
+// denseMargin is the vertical margin of dense timelines, enough for the descenders of the tick labels
+const denseMargin = 4
+
+// labelPadding is the distance in pixels between the labels anchored at the top or bottom and the row edges
+const labelPadding = 3
+
+// validID matches the event IDs accepted in strict mode
+var validID = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_:.-]*$`)
+
+// validCSSClass matches the class names accepted by SetClassColors
+var validCSSClass = regexp.MustCompile(`^-?[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// validCSSVar matches the names of the custom CSS properties accepted by SetCSSVars
+var validCSSVar = regexp.MustCompile(`^--[A-Za-z0-9_-]+$`)
+
 type EventType int
 
 const (
@@ -23,27 +107,153 @@ const (
 	EventTypeEra                   // A time period that spans vertically across all rows below it
 )
 
+// EraLabelPosition is the vertical placement of era labels within their spanned height
+type EraLabelPosition int
+
+const (
+	EraLabelTop    EraLabelPosition = iota // Near the top of the era, inside of its own row
+	EraLabelCenter                         // Vertically centered in the era
+	EraLabelBottom                         // Near the bottom of the era
+)
+
+// EraBorderStyle is the way the borders of the eras are stroked
+type EraBorderStyle int
+
+const (
+	EraBorderOpenSides EraBorderStyle = iota // Only the left and right sides are stroked
+	EraBorderFullBox                         // The four sides are stroked
+	EraBorderNone                            // No side is stroked
+)
+
+// RowOrder is the vertical order in which the rows are stacked
+type RowOrder int
+
+const (
+	RowOrderTopDown  RowOrder = iota // The first row is drawn at the top
+	RowOrderBottomUp                 // The first row is drawn at the bottom, next to the axis
+)
+
+// DateBoundary is the calendar unit of the ticks placed with SetDateBoundaryTicks
+type DateBoundary int
+
+const (
+	DateBoundaryNone DateBoundary = iota // Ticks evenly divide the axis
+	DateBoundaryHour                     // Ticks mark the start of each hour
+	DateBoundaryDay                      // Ticks mark the start of each day
+)
+
+// SmallLabelMode is how the labels too small to fit in their events are drawn
+type SmallLabelMode int
+
+const (
+	SmallLabelHide     SmallLabelMode = iota // The label is hidden
+	SmallLabelTruncate                       // The label is drawn at the minimum label font size and cut with an ellipsis
+	SmallLabelLeader                         // The label is drawn in a track above the rows and connected to its event by a line
+)
+
+// maxBoundaryTicks limits the number of ticks placed at date boundaries
+const maxBoundaryTicks = 1000
+
+// EventShape is the shape used to draw a task
+type EventShape int
+
+const (
+	ShapeRect    EventShape = iota // A plain rectangle
+	ShapeRounded                   // A rectangle with rounded corners
+	ShapeChevron                   // An arrow-like polygon pointing in the time direction
+)
+
 // Event represents a timeline event
 type Event struct {
-	Type     EventType     // type of the event - affects how it is drawn on the timeline
-	ID       string        // unique HTML identifier
+	Type        EventType     // type of the event - affects how it is drawn on the timeline
+	ID          string        // unique HTML identifier
+	Class       string        // CSS class name
+	Text        string        // text displayed inside of the event rectangle if the event duration provides sufficient width, newlines break it into centered lines
+	Title       string        // tooltip text
+	Duration    time.Duration // event duration
+	Time        time.Time     // absolute start time (leave zero for auto positioning by last duration)
+	Offset      time.Duration // start offset from the beginning of the timeline, mutually exclusive with Time (leave zero for auto positioning)
+	HasOffset   bool          // positions the event at Offset even when it is zero, positive offsets are always used
+	Gap         time.Duration // idle time before an auto positioned event, ignored when Time or Offset is set
+	Pattern     string        // fill pattern name ("hatch" or "dots"), useful to mark estimated durations
+	Fill        string        // inline fill color overriding the CSS style, ignored when Pattern is set
+	Outline     bool          // draws the task as a dashed outline without fill through the "tl-outline" class, ignoring Fill and Pattern
+	Shape       EventShape    // shape of the task (ignored for eras, which always span their rows as rectangles)
+	Segments    []Segment     // contiguous sub-phases of a task drawn instead of its shape, their durations must add up to Duration
+	URL         string        // link opened when clicking the event
+	ZIndex      int           // draw priority, events with higher values are drawn on top of the rest
+	Hidden      bool          // skips drawing the event while it still counts towards the axis range
+	Badge       string        // short annotation such as a retry count drawn in the top-right corner when the event is wide enough
+	SpanRows    int           // number of rows covered by an era starting with its own, 0 or more than the remaining rows cover all of them
+	Group       string        // name shared by consecutive events of a row to wrap them in a "tl-group" element drawn with the ZIndex of the first one
+	Opacity     float64       // fill opacity of the shape and its segments between 0 and 1 so overlapping events stay visible, 0 leaves it opaque so use Hidden instead of a transparent event
+	LabelAlways bool          // draws the label at the minimum font size instead of hiding it, next to the event when it does not fit inside, on its left near the right edge
+	Annotations []Annotation  // instants inside of the event marked with a tick and a label, such as the first byte of a request
+}
+
+// explicitOffset reports whether the event is positioned at its Offset instead of after the previous event
+func (e Event) explicitOffset() bool {
+	return e.HasOffset || e.Offset > 0
+}
+
+// Segment represents a sub-phase of a task drawn as part of a stacked bar
+//
+// Its rect gets the "tl-segment" class, so it can be styled with selectors like ".tl-segment.my-class".
+type Segment struct {
+	Duration time.Duration // segment duration
 	Class    string        // CSS class name
-	Text     string        // text displayed inside of the event rectangle if the event duration provides sufficient width
-	Title    string        // tooltip text
-	Duration time.Duration // event duration
-	Time     time.Time     // absolute start time (leave zero for auto positioning by last duration)
+	Text     string        // text displayed inside of the segment if it provides sufficient width (leave empty to suppress it)
+}
+
+// Annotation marks an instant inside of an event
+//
+// It is drawn as a "tl-annotation" group holding a tick across the event and its label.
+type Annotation struct {
+	Offset time.Duration // time from the start of the event, annotations past its Duration are skipped
+	Label  string        // text drawn next to the tick (leave empty to draw the tick only)
 }
 
+// band represents a shaded time interval spanning all rows
+type band struct {
+	start time.Time
+	end   time.Time
+	class string
+}
+
+// markerDef is a user supplied <marker> element injected in the definitions
+type markerDef struct {
+	id       string
+	fragment string
+}
+
+// EventGroup is the <g> element wrapping the children of a rendered event
+//
+// Its ID and Class can be modified and Elements accepts any value that can be
+// encoded with encoding/xml.
+type EventGroup = g
+
+// EventDecorator is called with each rendered event to post-process its group
+type EventDecorator func(e Event, group *EventGroup)
+
 // Row represents a row in the timeline
 type Row struct {
 	height          int
 	separatorHeight int
+	startOffset     time.Duration
+	below           bool
 	events          []Event
 }
 
 // Timeline represents the entire timeline
+//
+// Generating does not modify the timeline, so Generate and the other methods
+// computing its layout may be called concurrently on the same instance as long
+// as no setter runs at the same time. Use Clone to render variants of a timeline
+// from multiple goroutines.
 type Timeline struct {
-	rows []*Row
+	rows       []*Row
+	bands      []band
+	markerDefs []markerDef
 
 	id           string
 	width        string
@@ -56,33 +266,194 @@ type Timeline struct {
 	marginLeft   float64
 	marginRight  float64
 	style        string
+	styleHref    string
+	cssVars      map[string]string
+	classColors  map[string]string
+	fontName     string
+	fontData     []byte
+	background   string
+	chartTitle   string
+	titleClass   string
+	axisMarkers  [2]string
+	scale        float64
+	maxWidth     float64
+	padding      float64
+	aspectRatio  string
+	contentWidth int
+
+	durationFormat       DurationFormat
+	tickUnit             time.Duration
+	tickUnitSet          bool
+	tickLabelDigits      int
+	dateBoundary         DateBoundary
+	labelBaseline        string
+	numberLocale         language.Tag
+	eraLabelPosition     EraLabelPosition
+	eraBorderStyle       EraBorderStyle
+	smallLabelMode       SmallLabelMode
+	rowOrder             RowOrder
+	autoTicks            bool
+	targetTickSpacing    int
+	tickLabelSkipOverlap bool
+	endLabelsOnly        bool
+	axisStrokeWidth      int
+	tickStrokeWidth      int
+	tooltipDuration      bool
+	maxLabelFontSize     int
+	minLabelFontSize     int
+	allowNegative        bool
+	snapToTicks          bool
+	integerCoords        bool
+	clipContent          bool
+	targetHighlight      bool
+	dualAxisLabels       bool
+	originLabel          bool
+	originLine           bool
+	strictIDs            bool
+	dense                bool
+	eraLabelStacking     bool
+	autoTextContrast     bool
+	fontSizeUnit         string
+	interactive          bool
+	eventDecorator       EventDecorator
+	glyphWidth           func(r rune, fontSize float64) float64
+	maxDurationOverride  time.Duration
+	minDurationOverride  time.Duration
+	timeWindowStart      time.Time
+	timeWindowEnd        time.Time
+	durationWindowStart  time.Duration
+	durationWindowEnd    time.Duration
+}
+
+// layout holds the values derived from the timeline config on each Generate call
+type layout struct {
+	earliest          time.Time     // Earliest time within the timeline
+	windowOffset      time.Duration // Offset from earliest where the visible window starts
+	maxDuration       time.Duration
+	numTicks          int
+	timeMode          bool // events are positioned by their Time
+	tickLabelMargin   int
+	timeLabelMargin   int // space for the timestamp labels below the tick labels
+	originLabelMargin int // space for the origin label below the tick labels
+	titleHeight       int // space for the chart title above the rows
+	marginTop         int // vertical margins, reduced in dense mode
+	marginBottom      int
+	contentTop        int // Y coordinate where the rows start, below the title and the leader track
+	contentHeight     int // height of the rows above the axis
+	belowHeight       int // height of the rows below the axis
+	eraBottom         int // Y coordinate where the eras spanning all the rows below them end
+	totalHeight       int
+	contentWidth      float64
+	totalWidth        float64
+	padding           float64 // space added around the content by the viewBox
+	width             string  // SVG width attribute
+	height            string  // SVG height attribute
+
+	boxes     map[string]box // Rectangles of the events with an ID filled while drawing
+	events    []EventLayout  // Geometry of all the drawn events in drawing order
+	eraLabels []eraLabel     // Era labels placed so far when stacking them
+
+	leaders     []leaderLabel // Labels moved to the leader track while drawing
+	leaderLanes []int         // Lanes of the leader labels computed by setup, in drawing order
+	tickXs      []float64     // X coordinates of the ticks the events snap to, in increasing order
+}
+
+// viewBoxSize returns the size of the viewBox, the content with the padding on all sides
+func (l *layout) viewBoxSize() (w, h float64) {
+	return l.totalWidth + 2*l.padding, float64(l.totalHeight) + 2*l.padding
+}
+
+// eraLabel is the placement of an era label
+type eraLabel struct {
+	startX, y, size float64
+}
+
+// stackEraLabel returns the y coordinate of an era label moved off the labels of eras starting at the same x
+//
+// Labels are moved down, or up when they are placed at the bottom of the eras.
+func (l *layout) stackEraLabel(startX, y, size float64, up bool) float64 {
+	const tolerance = 3 // maximum distance in pixels between the starts of stacked eras
+	step := size + 2
+	if up {
+		step = -step
+	}
+	for moved := true; moved; {
+		moved = false
+		for _, other := range l.eraLabels {
+			if math.Abs(other.startX-startX) <= tolerance && math.Abs(other.y-y) < (other.size+size)/2 {
+				y += step
+				moved = true
+			}
+		}
+	}
+	l.eraLabels = append(l.eraLabels, eraLabel{startX: startX, y: y, size: size})
+	return y
+}
+
+// leaderLabel is the horizontal extent of a label moved to the leader track
+type leaderLabel struct {
+	start, end float64
+}
 
-	earliest        time.Time // Earliest time within the timeline
-	maxDuration     time.Duration
-	tickLabelMargin int
-	contentHeight   int
-	totalHeight     int
-	contentWidth    float64
-	totalWidth      float64
+// box is the rectangle drawn for an event
+type box struct {
+	x, y, w, h float64
 }
 
 // NewTimeline creates a new timeline with default config
 func NewTimeline() *Timeline {
 	return &Timeline{
-		rows:         make([]*Row, 0),
-		id:           "",
-		width:        "100%",
-		precision:    float64(1000),
-		numTicks:     8,
-		tickHeight:   5,
-		marginTop:    15,
-		marginBottom: 15,
-		marginLeft:   10,
-		marginRight:  30,
-		style:        DefaultStyle,
+		rows:              make([]*Row, 0),
+		id:                "",
+		width:             "100%",
+		precision:         float64(1000),
+		numTicks:          8,
+		targetTickSpacing: 80,
+		tickHeight:        5,
+		tickLabelDigits:   2,
+		minLabelFontSize:  3,
+		marginTop:         15,
+		marginBottom:      15,
+		marginLeft:        10,
+		marginRight:       30,
+		style:             DefaultStyle,
+		scale:             1,
+		aspectRatio:       "xMinYMin meet",
 	}
 }
 
+// Clone returns a deep copy of the timeline including its rows and events
+func (t *Timeline) Clone() *Timeline {
+	c := *t
+	c.cssVars = maps.Clone(t.cssVars)
+	c.classColors = maps.Clone(t.classColors)
+	c.bands = slices.Clone(t.bands)
+	c.markerDefs = slices.Clone(t.markerDefs)
+	c.rows = make([]*Row, 0, len(t.rows))
+	for _, r := range t.rows {
+		c.rows = append(c.rows, r.clone())
+	}
+	return &c
+}
+
+// Append adds copies of the rows of another timeline after the rows of t
+//
+// The events and heights of the rows are preserved while the config and bands of
+// the other timeline are ignored.
+func (t *Timeline) Append(other *Timeline) {
+	for _, r := range other.rows {
+		t.rows = append(t.rows, r.clone())
+	}
+}
+
+// Reset drops all rows, events and bands so the timeline can be reused
+//
+// The config set with the SetX methods survives a Reset.
+func (t *Timeline) Reset() {
+	t.rows = make([]*Row, 0)
+	t.bands = nil
+}
+
 // SetID sets the unique HTML identifier of the timeline SVG
 func (t *Timeline) SetID(id string) {
 	t.id = id
@@ -109,310 +480,2304 @@ func (t *Timeline) SetHeight(height string) {
 	t.height = height
 }
 
-// SetNumTicks sets the number of ticks for the timeline
-func (t *Timeline) SetNumTicks(n int) {
-	t.numTicks = n
+// SetContentWidth sets the width of the drawing area inside of the margins in viewBox units (default: 0, derived from the precision)
+//
+// The viewBox grows with the content while SetWidth keeps setting the displayed
+// width, so a fixed pixel width with a wider content can be scrolled or zoomed.
+func (t *Timeline) SetContentWidth(px int) {
+	t.contentWidth = px
+}
+
+// SetAxisStrokeWidth sets the stroke-width attribute of the axis line (default: 0, styled by CSS only)
+//
+// The attribute keeps the SVG legible for converters that ignore CSS, rules of the
+// style still take precedence over it.
+func (t *Timeline) SetAxisStrokeWidth(w int) {
+	t.axisStrokeWidth = w
+}
+
+// SetTickStrokeWidth sets the stroke-width attribute of the tick lines (default: 0, styled by CSS only)
+func (t *Timeline) SetTickStrokeWidth(w int) {
+	t.tickStrokeWidth = w
+}
+
+// SetMaxWidth caps the width of the viewBox shrinking the content to fit within the margins (default: 0, no cap)
+//
+// It limits the extent of the drawing when a high precision is set, while SetWidth
+// only sets the width attribute the SVG is rendered at.
+func (t *Timeline) SetMaxWidth(px float64) {
+	t.maxWidth = px
+}
+
+// SetViewBoxPadding enlarges the viewBox by the given padding on all sides without moving the content (default: 0)
+//
+// Unlike the margins it leaves the coordinates of the drawing untouched, giving some
+// breathing room to a timeline embedded flush against other elements.
+func (t *Timeline) SetViewBoxPadding(p float64) {
+	t.padding = p
+}
+
+// SetPreserveAspectRatio sets the preserveAspectRatio attribute of the SVG (default: "xMinYMin meet")
+//
+// The viewBox always uses the logical size of the content, so with a percentage
+// height such as "100%" the timeline scales to fill its container. Use "none" to
+// stretch the content to the whole container ignoring its aspect ratio.
+func (t *Timeline) SetPreserveAspectRatio(value string) {
+	t.aspectRatio = value
+}
+
+// SetScale scales the rendered SVG uniformly by the given factor (default: 1)
+//
+// Only the width and height attributes of the SVG are multiplied, the viewBox stays
+// in logical units so the browser scales every coordinate and font size along with
+// them. Percentage sizes are relative to the container and are left untouched.
+func (t *Timeline) SetScale(factor float64) {
+	t.scale = factor
+}
+
+// SetNumTicks sets the number of ticks for the timeline
+func (t *Timeline) SetNumTicks(n int) {
+	t.numTicks = n
+}
+
+// SetAutoTicks picks the number of ticks from the content width instead of using SetNumTicks
+//
+// The spacing between ticks is set with SetTargetTickSpacing, with a minimum of 2 ticks.
+func (t *Timeline) SetAutoTicks(auto bool) {
+	t.autoTicks = auto
+}
+
+// SetTargetTickSpacing sets the desired distance between ticks when auto ticks are enabled (default: 80)
+func (t *Timeline) SetTargetTickSpacing(px int) {
+	t.targetTickSpacing = px
+}
+
+// SetTickHeight sets the height of the timeline ticks
+func (t *Timeline) SetTickHeight(h int) {
+	t.tickHeight = h
+}
+
+// SetDurationFormat sets the format of the tick labels (default: DurationFormatGo)
+func (t *Timeline) SetDurationFormat(style DurationFormat) {
+	t.durationFormat = style
+}
+
+// SetTickUnit formats every tick label as a decimal value of the given unit, such as 10800s for time.Second
+//
+// The unit must be one of time.Hour, time.Minute, time.Second, time.Millisecond,
+// time.Microsecond or time.Nanosecond and overrides the duration format. When a
+// number locale is set the digits are grouped with its thousands separator.
+func (t *Timeline) SetTickUnit(unit time.Duration) {
+	t.tickUnit = unit
+	t.tickUnitSet = true
+}
+
+// SetTickLabelDigits sets the number of digits the tick labels are rounded to (default: 2)
+//
+// With 3 digits a tick at 1437ms is labeled "1.437s", and "1s" with 0 digits. More
+// digits are still used when adjacent ticks would otherwise share the same label.
+func (t *Timeline) SetTickLabelDigits(digits int) {
+	t.tickLabelDigits = digits
+}
+
+// SetDateBoundaryTicks places the ticks at each hour or day boundary in time mode (default: DateBoundaryNone)
+//
+// Boundaries follow the location of the event times, so days keep starting at
+// midnight across DST changes. The ticks are labeled with the date, or with the
+// time of day for hours, and the start and end of the axis get an unlabeled tick
+// unless a boundary falls on them. SetNumTicks and SetEndLabelsOnly are ignored,
+// and timelines without Time set on their events keep the evenly spaced ticks.
+func (t *Timeline) SetDateBoundaryTicks(unit DateBoundary) {
+	t.dateBoundary = unit
+}
+
+// SetEraLabelPosition sets the vertical placement of era labels (default: EraLabelTop)
+func (t *Timeline) SetEraLabelPosition(pos EraLabelPosition) {
+	t.eraLabelPosition = pos
+}
+
+// SetEraBorderStyle sets which sides of the eras are stroked (default: EraBorderOpenSides)
+func (t *Timeline) SetEraBorderStyle(style EraBorderStyle) {
+	t.eraBorderStyle = style
+}
+
+// SetLabelBaseline sets the dominant-baseline of the task labels (default: "middle")
+//
+// Labels with a "hanging" or "text-top" baseline sit at the top of their row and
+// the "auto", "alphabetic", "ideographic" and "text-bottom" ones at its bottom,
+// "middle" and "central" keep them vertically centered. Era labels are placed
+// with SetEraLabelPosition instead.
+func (t *Timeline) SetLabelBaseline(baseline string) {
+	t.labelBaseline = baseline
+}
+
+// SetSmallEventLabelMode sets how the labels too small to fit in their events are drawn (default: SmallLabelHide)
+//
+// It applies to the labels that would be drawn below the size set with SetMinLabelFontSize.
+// With SmallLabelLeader the timeline grows to fit a track above the rows where
+// the labels are stacked to avoid overlapping each other. GenerateRows has no
+// room for the track and hides those labels.
+func (t *Timeline) SetSmallEventLabelMode(mode SmallLabelMode) {
+	t.smallLabelMode = mode
+}
+
+// SetRowOrder sets the vertical order of the rows (default: RowOrderTopDown)
+//
+// In RowOrderBottomUp eras span upwards from their row to the top of the timeline
+// and EraLabelTop and EraLabelBottom are mirrored so labels stay in the era's own row.
+func (t *Timeline) SetRowOrder(order RowOrder) {
+	t.rowOrder = order
+}
+
+// SetAutoTextContrast picks black or white labels for the events with an inline Fill by its luminance (default: false)
+//
+// Events styled by CSS keep the label color of the style. Only hex and rgb()
+// fills are understood, other colors keep it too.
+func (t *Timeline) SetAutoTextContrast(auto bool) {
+	t.autoTextContrast = auto
+}
+
+// SetEraLabelStacking stacks the labels of eras starting at the same x so they do not overlap (default: false)
+func (t *Timeline) SetEraLabelStacking(stack bool) {
+	t.eraLabelStacking = stack
+}
+
+// SetNumberLocale sets the locale used to format the decimal numbers of the durations
+//
+// It affects the tick labels and the tooltip durations in the DurationFormatDecimalUnit
+// format and the tick labels set with SetTickUnit, for example language.Spanish uses a
+// comma as decimal separator (default: language.Und, dot-separated English formatting).
+func (t *Timeline) SetNumberLocale(tag language.Tag) {
+	t.numberLocale = tag
+}
+
+// SetInteractive renders custom tooltips shown instantly on hover by an embedded script (default: false)
+//
+// The tooltips include the event text and duration and replace the native <title>
+// tooltips. Leave it disabled for embeds where scripts are not allowed.
+func (t *Timeline) SetInteractive(interactive bool) {
+	t.interactive = interactive
+}
+
+// SetFontSizeUnit sets the unit of the font sizes of the labels: "px" (default), "em" or "rem"
+//
+// Relative units divide the pixel sizes by a base of 16, so 12px labels become 0.75em.
+func (t *Timeline) SetFontSizeUnit(unit string) {
+	t.fontSizeUnit = unit
+}
+
+// SetGlyphWidthFunc sets the function measuring the width of a glyph to fit the labels with proportional fonts
+//
+// The widths of the runes of a label are added up instead of approximating every
+// glyph as monospace. Pass nil to restore the approximation.
+func (t *Timeline) SetGlyphWidthFunc(fn func(r rune, fontSize float64) float64) {
+	t.glyphWidth = fn
+}
+
+// SetDense removes the separators between rows and shrinks the vertical margins for a compact timeline (default: false)
+//
+// The rows keep their configured separators, so the same timeline can be generated
+// in both layouts. Margins already smaller than the dense ones are kept.
+func (t *Timeline) SetDense(dense bool) {
+	t.dense = dense
+}
+
+// SetStrictIDs rejects duplicate event IDs and IDs that are not valid identifiers (default: false)
+//
+// Valid IDs start with a letter followed by letters, digits, '_', ':', '.' or '-'.
+func (t *Timeline) SetStrictIDs(strict bool) {
+	t.strictIDs = strict
+}
+
+// SetDualAxisLabels shows the absolute timestamp below the duration of each tick in time mode (default: false)
+//
+// The timestamps use the location of the event times and include the date when the axis spans several days.
+func (t *Timeline) SetDualAxisLabels(dual bool) {
+	t.dualAxisLabels = dual
+}
+
+// SetShowOriginLabel shows the start time of the timeline below the tick labels in time mode (default: false)
+//
+// The label gets the "tl-origin" class and gives context to the relative durations of the ticks.
+func (t *Timeline) SetShowOriginLabel(show bool) {
+	t.originLabel = show
+}
+
+// SetShowOriginLine draws a vertical line from the top of the rows to the axis at its start (default: false)
+//
+// The line gets the "tl-origin" class and is drawn beneath the events.
+func (t *Timeline) SetShowOriginLine(show bool) {
+	t.originLine = show
+}
+
+// SetEndLabelsOnly draws every tick mark but labels only the first and the last tick (default: false)
+func (t *Timeline) SetEndLabelsOnly(endsOnly bool) {
+	t.endLabelsOnly = endsOnly
+}
+
+// SetTickLabelSkipOverlap skips tick labels that would overlap the previously drawn one
+//
+// Tick marks are always drawn (default: false).
+func (t *Timeline) SetTickLabelSkipOverlap(skip bool) {
+	t.tickLabelSkipOverlap = skip
+}
+
+// SetTooltipIncludeDuration appends the event duration to the tooltips
+//
+// In time mode the start and end timestamps are included too. Events without
+// a Title get a tooltip with only this information (default: false).
+func (t *Timeline) SetTooltipIncludeDuration(include bool) {
+	t.tooltipDuration = include
+}
+
+// SetMaxLabelFontSize caps the font size of the event labels regardless of the row height (default: 0, no cap)
+func (t *Timeline) SetMaxLabelFontSize(px int) {
+	t.maxLabelFontSize = px
+}
+
+// SetMinLabelFontSize sets the font size below which the event labels are hidden (default: 3)
+//
+// Labels of events with LabelAlways set are drawn at this size instead of being hidden.
+func (t *Timeline) SetMinLabelFontSize(px int) {
+	t.minLabelFontSize = px
+}
+
+// SetAllowNegativeDurations renders tasks with a negative duration as bars extending
+// to the left of their start instead of returning an error (default: false)
+//
+// Those tasks get the "tl-negative" class and the axis range includes their extent.
+func (t *Timeline) SetAllowNegativeDurations(allow bool) {
+	t.allowNegative = allow
+}
+
+// SetSnapToTicks aligns the start and end of the events to the nearest tick (default: false)
+//
+// Snapping is purely visual, so adjacent events falling in the same tick cell may merge or overlap.
+func (t *Timeline) SetSnapToTicks(snap bool) {
+	t.snapToTicks = snap
+}
+
+// SetIntegerCoords rounds the coordinates and sizes of the drawn elements to whole pixels (default: false)
+//
+// Both edges of the events are rounded, so adjacent events keep sharing an edge
+// instead of overlapping or leaving gaps between them.
+func (t *Timeline) SetIntegerCoords(integer bool) {
+	t.integerCoords = integer
+}
+
+// SetClipContent clips the events at the left and right edges of the content area (default: false)
+//
+// Labels and bars overflowing the content, such as those widened to their minimum
+// width, are cut at its edges instead of spilling into the margins.
+func (t *Timeline) SetClipContent(clip bool) {
+	t.clipContent = clip
+}
+
+// SetTargetHighlight appends a :target rule to the style highlighting the event whose
+// ID matches the URL fragment, so links like "#my-event" point to it (default: false)
+//
+// The highlight can be overridden in CSS with the ".tl-event:target" and ".tl-era:target" selectors.
+func (t *Timeline) SetTargetHighlight(highlight bool) {
+	t.targetHighlight = highlight
+}
+
+// SetEventDecorator sets a callback called for each event after its standard children
+// are rendered, allowing to add custom attributes or elements to the event group
+func (t *Timeline) SetEventDecorator(decorator EventDecorator) {
+	t.eventDecorator = decorator
+}
+
+// SetMaxDurationOverride forces the duration covered by the axis instead of the computed one
+//
+// Events extending past it are clipped at the content edge. Zero disables the override.
+func (t *Timeline) SetMaxDurationOverride(d time.Duration) {
+	t.maxDurationOverride = d
+}
+
+// SetMinDurationOverride sets the minimum duration covered by the axis
+//
+// Zero disables the override.
+func (t *Timeline) SetMinDurationOverride(d time.Duration) {
+	t.minDurationOverride = d
+}
+
+// SetTimeWindow renders only the given time range across the full width (time mode only)
+//
+// Events partially outside of the window are clipped and the axis ticks span only the window.
+// The window takes precedence over the duration overrides.
+func (t *Timeline) SetTimeWindow(start, end time.Time) {
+	t.timeWindowStart = start
+	t.timeWindowEnd = end
+}
+
+// SetDurationWindow renders only the range between the given offsets from the timeline start
+//
+// It behaves like SetTimeWindow but also works when the events have no Time set.
+func (t *Timeline) SetDurationWindow(start, end time.Duration) {
+	t.durationWindowStart = start
+	t.durationWindowEnd = end
+}
+
+// SetMargins sets the margins of the timeline inside of the SVG
+func (t *Timeline) SetMargins(top, right, bottom, left int) {
+	t.marginTop = top
+	t.marginBottom = bottom
+	t.marginLeft = float64(left)
+	t.marginRight = float64(right)
+}
+
+// SetMarginTop sets the top margin of the timeline inside of the SVG
+func (t *Timeline) SetMarginTop(px int) {
+	t.marginTop = px
+}
+
+// SetMarginRight sets the right margin of the timeline inside of the SVG
+func (t *Timeline) SetMarginRight(px int) {
+	t.marginRight = float64(px)
+}
+
+// SetMarginBottom sets the bottom margin of the timeline inside of the SVG
+func (t *Timeline) SetMarginBottom(px int) {
+	t.marginBottom = px
+}
+
+// SetMarginLeft sets the left margin of the timeline inside of the SVG
+func (t *Timeline) SetMarginLeft(px int) {
+	t.marginLeft = float64(px)
+}
+
+// SetStyle sets the CSS style for the timeline (for reference use the value of DefaultStyle)
+func (t *Timeline) SetStyle(s string) {
+	t.style = s
+}
+
+// SetStyleHref references an external CSS stylesheet instead of inlining the style
+//
+// The SVG starts with an xml-stylesheet processing instruction and GenerateHTML adds a
+// <link> to the page. The href takes precedence and the style set with SetStyle is
+// not inlined while it is set, pass an empty string to inline it again.
+func (t *Timeline) SetStyleHref(url string) {
+	t.styleHref = url
+}
+
+// SetCSSVars sets CSS custom properties injected in a :root block ahead of the style
+//
+// The default style is expressed in terms of variables like --tl-bar-fill or --tl-era-fill,
+// so a few colors can be changed without rewriting it. The "--" prefix is optional.
+// Names must be CSS identifiers and values cannot contain semicolons or braces.
+// The map is copied, later changes to it do not affect the timeline.
+func (t *Timeline) SetCSSVars(vars map[string]string) {
+	t.cssVars = maps.Clone(vars)
+}
+
+// SetClassColors sets the fill color of the events with each class
+//
+// The rules are appended to the style, so events can be colored by category
+// without writing CSS. Combine it with GeneratePalette for distinct colors.
+// Classes must be CSS identifiers and colors cannot contain semicolons or braces.
+// The map is copied, later changes to it do not affect the timeline.
+func (t *Timeline) SetClassColors(colors map[string]string) {
+	t.classColors = maps.Clone(colors)
+}
+
+// SetEmbeddedFont embeds a WOFF2 font in the style and uses it for all the labels
+//
+// The SVG then renders the same across machines, an empty name restores the default monospace font.
+// The name cannot contain quotes, backslashes, braces, semicolons or angle brackets.
+func (t *Timeline) SetEmbeddedFont(name string, woff2 []byte) {
+	t.fontName = name
+	t.fontData = woff2
+}
+
+// SetBackground sets the fill color of the background directly on the SVG element
+//
+// Useful when the SVG is embedded without CSS, the "tl-bg" class is kept so stylesheets
+// can still override it (default: none).
+func (t *Timeline) SetBackground(color string) {
+	t.background = color
+}
+
+// AddMarkerDef injects a raw <marker> element in the definitions of the SVG
+//
+// The fragment must be well-formed XML with a single <marker> root whose id matches
+// the given one, so it can be referenced with url(#id) from SetAxisMarkers or from
+// a marker-start or marker-end CSS property. The fragment is validated on Generate.
+func (t *Timeline) AddMarkerDef(id, svgFragment string) {
+	t.markerDefs = append(t.markerDefs, markerDef{id: id, fragment: svgFragment})
+}
+
+// SetAxisMarkers sets the ids of the markers drawn at the start and end of the axis line
+//
+// The markers must be added with AddMarkerDef, an empty id leaves that end of the axis bare.
+func (t *Timeline) SetAxisMarkers(start, end string) {
+	t.axisMarkers = [2]string{start, end}
+}
+
+// SetChartTitle sets a heading centered above the rows, an empty text removes it
+//
+// The title gets the given class or "tl-title" when empty, and the timeline grows to fit it.
+func (t *Timeline) SetChartTitle(text, class string) {
+	t.chartTitle = text
+	t.titleClass = class
+}
+
+// AddRow adds a new row to the timeline
+func (t *Timeline) AddRow(height int, separatorHeight int) *Row {
+	row := &Row{
+		height:          height,
+		separatorHeight: separatorHeight,
+		events:          make([]Event, 0),
+	}
+	t.rows = append(t.rows, row)
+	return row
+}
+
+// AppendRow appends an existing row, such as one yielded by ParseCFG, to the timeline
+func (t *Timeline) AppendRow(row *Row) {
+	t.rows = append(t.rows, row)
+}
+
+// AddBand adds a shaded band spanning all rows between two times (time mode only)
+//
+// Bands are drawn behind the events in insertion order and extend the axis range if needed.
+func (t *Timeline) AddBand(start, end time.Time, class string) {
+	t.bands = append(t.bands, band{start: start, end: end, class: class})
+}
+
+// AddAutoRow adds a new row whose height is sized to fit its tallest event label at a font size of 12
+//
+// Auto-sized and fixed rows can be mixed in the same timeline, AddRow with a height of 0 is equivalent.
+func (t *Timeline) AddAutoRow(separatorHeight int) *Row {
+	return t.AddRow(0, separatorHeight)
+}
+
+// GetRows returns the timeline rows
+func (t *Timeline) GetRows() []*Row {
+	return t.rows
+}
+
+// GetRowByIndex returns the row at the index or nil if not found
+func (t *Timeline) GetRowByIndex(i int) *Row {
+	if i < 0 || i >= len(t.rows) {
+		return nil
+	}
+	return t.rows[i]
+}
+
+// RowCount returns the number of rows of the timeline
+func (t *Timeline) RowCount() int {
+	return len(t.rows)
+}
+
+// GetLastRow returns the last row
+func (t *Timeline) GetLastRow() *Row {
+	if len(t.rows) == 0 {
+		return nil
+	}
+	return t.rows[len(t.rows)-1]
+}
+
+// EventBox returns the rectangle drawn for the event with the given ID in SVG viewBox units
+//
+// Clipped events report their visible part only. ok is false when no event
+// with the ID is drawn or the timeline cannot be generated.
+func (t *Timeline) EventBox(id string) (x, y, w, h float64, ok bool) {
+	l, err := t.setup()
+	if err != nil {
+		return 0, 0, 0, 0, false
+	}
+	t.drawRows(l)
+	b, ok := l.boxes[id]
+	return b.x, b.y, b.w, b.h, ok
+}
+
+// Dimensions returns the width and height of the SVG viewBox as computed by Generate
+//
+// Zero values are returned when the timeline cannot be generated.
+func (t *Timeline) Dimensions() (w, h float64) {
+	l, err := t.setup()
+	if err != nil {
+		return 0, 0
+	}
+	return l.viewBoxSize()
+}
+
+// EstimateTextWidth returns the width in viewBox units the labels use to fit a text of the given font size
+//
+// It applies the glyph width function set with SetGlyphWidthFunc, or the monospace
+// approximation otherwise, and measures the widest line of multi-line texts.
+func (t *Timeline) EstimateTextWidth(s string, fontSize float64) float64 {
+	return t.textWidth(s, fontSize)
+}
+
+// MaxDuration returns the maximum duration across all rows
+func (t *Timeline) MaxDuration() time.Duration {
+	var m time.Duration
+	start := t.StartTime()
+	for _, row := range t.rows {
+		duration := row.TotalDuration(start)
+		if duration > m {
+			m = duration
+		}
+	}
+	for _, b := range t.bands {
+		m = max(m, b.end.Sub(start))
+	}
+	return m
+}
+
+// TotalRowHeight calculates the total height of all rows including separators
+func (t *Timeline) TotalRowHeight() int {
+	total := 0
+	for _, row := range t.rows {
+		total += row.layoutHeight() + t.separatorHeight(row)
+	}
+	return total
+}
+
+// StartTime returns the earliest time that is currently set on the timeline
+// given the existing rows and events
+func (t *Timeline) StartTime() time.Time {
+	var earliest time.Time
+	for _, r := range t.rows {
+		rowStartTime := r.StartTime()
+		if earliest.IsZero() || rowStartTime.Before(earliest) {
+			earliest = rowStartTime
+		}
+	}
+	for _, b := range t.bands {
+		if earliest.IsZero() || b.start.Before(earliest) {
+			earliest = b.start
+		}
+	}
+	return earliest
+}
+
+// EndTime returns the latest time that is currently set on the timeline
+// given the added rows and events (including their durations)
+func (t *Timeline) EndTime() time.Time {
+	var end time.Time
+	for _, r := range t.rows {
+		rowEndTime := r.EndTime()
+		if end.IsZero() || rowEndTime.After(end) {
+			end = rowEndTime
+		}
+	}
+	for _, b := range t.bands {
+		if end.IsZero() || b.end.After(end) {
+			end = b.end
+		}
+	}
+	return end
+}
+
+// Generate generates the timeline SVG with the current configuration
+//
+// The timeline config is left untouched so calling Generate again renders the same output.
+func (t *Timeline) Generate() (string, error) {
+	l, err := t.setup()
+	if err != nil {
+		return "", err
+	}
+	return t.render(l, true)
+}
+
+// GenerateRows generates a standalone SVG for each row sharing the time scale of the whole timeline
+//
+// The SVGs have the same width and leave out the axis so they line up when stacked,
+// for example to lazily render the rows of a long list. When the timeline has an
+// ID, each SVG gets the ID with a "-row-N" suffix.
+func (t *Timeline) GenerateRows() ([]string, error) {
+	l, err := t.setup()
+	if err != nil {
+		return nil, err
+	}
+
+	svgs := make([]string, 0, len(t.rows))
+	for i, row := range t.rows {
+		single := *t
+		r := *row
+		r.below = false
+		single.rows = []*Row{&r}
+		if t.id != "" {
+			single.id = fmt.Sprintf("%s-row-%d", t.id, i)
+		}
+
+		rl := *l
+		rl.titleHeight = 0
+		rl.contentTop = l.marginTop
+		rl.leaderLanes = nil
+		rl.contentHeight = row.layoutHeight() + t.separatorHeight(row)
+		rl.belowHeight = 0
+		rl.totalHeight = rl.contentHeight + l.marginTop + l.marginBottom
+		rl.eraBottom = rl.contentTop + row.layoutHeight()
+		_, h := rl.viewBoxSize()
+		rl.height = scaleLength(strconv.FormatFloat(h, 'f', -1, 64), t.scale)
+
+		svg, err := single.render(&rl, false)
+		if err != nil {
+			return nil, err
+		}
+		svgs = append(svgs, svg)
+	}
+	return svgs, nil
+}
+
+// render renders the SVG document with the given layout, the axis is only drawn when requested
+func (t *Timeline) render(l *layout, axis bool) (string, error) {
+	origin := 0 - l.padding // not -l.padding, which prints a negative zero
+	width, height := l.viewBoxSize()
+	root := svg{
+		Xmlns:               "http://www.w3.org/2000/svg",
+		ID:                  t.id,
+		Width:               l.width,
+		Height:              l.height,
+		ViewBox:             fmt.Sprintf("%v %v %f %f", origin, origin, width, height),
+		PreserveAspectRatio: t.aspectRatio,
+	}
+	if t.usesLinks() {
+		root.XmlnsXlink = "http://www.w3.org/1999/xlink"
+	}
+
+	// Definitions
+	defs := svgDefs{}
+	inlineStyle := t.style
+	if t.styleHref != "" {
+		inlineStyle = ""
+	}
+	if style := t.fontFaceRule() + t.cssVarsRule() + inlineStyle + t.classColorsRule() + t.targetRule() + t.tooltipRule(); style != "" {
+		defs.Elements = append(defs.Elements, svgStyle{Content: style})
+	}
+	if t.usesPatterns() {
+		defs.Content = patternDefs
+	}
+	for _, m := range t.markerDefs {
+		defs.Content += m.fragment
+	}
+	if t.clipContent {
+		defs.Elements = append(defs.Elements, clipPath{ID: t.clipID(), Elements: []any{
+			rect{X: t.marginLeft, Y: 0, Width: l.contentWidth, Height: float64(l.totalHeight)},
+		}})
+	}
+	root.Elements = append(root.Elements, defs)
+
+	// Background
+	background := "none"
+	if t.background != "" {
+		background = t.background
+	}
+	root.Elements = append(root.Elements,
+		rect{Class: "tl-bg", X: origin, Y: origin, Width: width, Height: height, Fill: background},
+	)
+
+	// Title
+	if l.titleHeight > 0 {
+		class := t.titleClass
+		if class == "" {
+			class = "tl-title"
+		}
+		root.Elements = append(root.Elements,
+			text{Class: class, X: t.coord(t.marginLeft + l.contentWidth/2), Y: float64(l.marginTop + titleFontSize/2), FontSize: t.fontSize(titleFontSize), FontFamily: t.fontFamily(), TextAnchor: "middle", DominantBaseline: "middle", Content: t.chartTitle},
+		)
+	}
+
+	// Draw bands
+	for _, b := range t.bands {
+		t.drawBand(l, &root, b)
+	}
+
+	if axis && t.originLine {
+		timelineY := l.contentTop + l.contentHeight + t.tickHeight
+		root.Elements = append(root.Elements,
+			line{Class: "tl-origin", X1: t.marginLeft, Y1: float64(l.contentTop), X2: t.marginLeft, Y2: float64(timelineY)},
+		)
+	}
+
+	// Draw rows
+	if t.clipContent {
+		root.Elements = append(root.Elements, g{ClipPath: "url(#" + t.clipID() + ")", Elements: t.drawRows(l)})
+	} else {
+		root.Elements = append(root.Elements, t.drawRows(l)...)
+	}
+
+	if axis {
+		t.drawAxis(l, &root)
+	}
+
+	// Interactive tooltip, drawn last to stay on top
+	if t.interactive {
+		root.Elements = append(root.Elements,
+			g{Class: "tl-tooltip", Elements: []any{
+				rect{},
+				text{FontSize: t.fontSize(12), FontFamily: t.fontFamily(), DominantBaseline: "hanging"},
+			}},
+			script{Content: "<![CDATA[\n" + tooltipScript + "]]>"},
+		)
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(inlineStyle) + svgBytesPerEvent*t.eventCount())
+	if t.styleHref != "" {
+		sb.WriteString(`<?xml-stylesheet type="text/css" href="`)
+		xml.EscapeText(&sb, []byte(t.styleHref))
+		sb.WriteString("\"?>\n")
+	}
+	encoder := xml.NewEncoder(&sb)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// clipID returns the id of the clipPath of the content area, unique per timeline ID
+func (t *Timeline) clipID() string {
+	if t.id != "" {
+		return t.id + "-clip"
+	}
+	return "tl-clip-content"
+}
+
+// drawAxis draws the timeline axis with its tick marks and labels
+func (t *Timeline) drawAxis(l *layout, root *svg) {
+	timelineY := l.contentTop + l.contentHeight + t.tickHeight
+	axis := line{Class: "tl-axis", X1: t.marginLeft, Y1: float64(timelineY), X2: t.marginLeft + l.contentWidth, Y2: float64(timelineY), StrokeWidth: t.axisStrokeWidth}
+	if id := t.axisMarkers[0]; id != "" {
+		axis.MarkerStart = "url(#" + id + ")"
+	}
+	if id := t.axisMarkers[1]; id != "" {
+		axis.MarkerEnd = "url(#" + id + ")"
+	}
+	root.Elements = append(root.Elements, axis)
+
+	// Draw tick marks and labels
+	group := g{Class: "tl-ticks"}
+	ticks := t.ticks(l)
+	for _, tick := range ticks {
+		// Tick mark
+		topY := timelineY - t.tickHeight
+		if tick.Index == 0 || tick.Index == len(ticks)-1 {
+			topY = l.contentTop
+		}
+		group.Elements = append(group.Elements,
+			line{X1: tick.X, Y1: float64(topY), X2: tick.X, Y2: float64(timelineY + t.tickHeight), StrokeWidth: t.tickStrokeWidth},
+		)
+
+		// Tick label
+		if tick.Label == "" {
+			continue
+		}
+		labelX, anchor := tick.X, "middle"
+		if t.endLabelsOnly {
+			// Keep the end labels inside of the content area
+			labelX, anchor = t.labelPosition(l, tick.X, t.tickLabelWidth(tick.Label, tick.timeLabel))
+		}
+		labelY := float64(timelineY + t.tickHeight + l.tickLabelMargin)
+		group.Elements = append(group.Elements,
+			text{X: labelX, Y: labelY, FontSize: t.fontSize(tickFontSize), FontFamily: t.fontFamily(), TextAnchor: anchor, Content: tick.Label},
+		)
+		if tick.timeLabel != "" {
+			group.Elements = append(group.Elements,
+				text{X: labelX, Y: labelY + float64(l.timeLabelMargin), FontSize: t.fontSize(tickFontSize), FontFamily: t.fontFamily(), TextAnchor: anchor, Content: tick.timeLabel},
+			)
+		}
+	}
+
+	// Origin label
+	if l.originLabelMargin > 0 {
+		labelY := float64(timelineY + t.tickHeight + l.tickLabelMargin + l.timeLabelMargin + l.originLabelMargin)
+		group.Elements = append(group.Elements,
+			text{Class: "tl-origin", X: t.marginLeft, Y: labelY, FontSize: t.fontSize(tickFontSize), FontFamily: t.fontFamily(), TextAnchor: "start", Content: "start: " + l.earliest.Format("2006-01-02 15:04:05.999999999")},
+		)
+	}
+	root.Elements = append(root.Elements, group)
+}
+
+// setup computes the layout of the timeline and ensures consistency across events
+// - if any event sets its Time, all events must set it
+// - at least one event must have a duration greater than 0
+func (t *Timeline) setup() (*layout, error) {
+	var duration time.Duration
+	ids := make(map[string]bool)
+
+	for _, r := range t.rows {
+		if r.startOffset < 0 {
+			return nil, fmt.Errorf("the start offset of rows cannot be negative")
+		}
+		if r.height < 0 {
+			return nil, fmt.Errorf("the height of rows cannot be negative, got %d", r.height)
+		}
+		for _, e := range r.events {
+			if t.strictIDs && e.ID != "" {
+				if !validID.MatchString(e.ID) {
+					return nil, fmt.Errorf("invalid event ID %q: must start with a letter and contain no spaces", e.ID)
+				}
+				if ids[e.ID] {
+					return nil, fmt.Errorf("duplicate event ID %q", e.ID)
+				}
+				ids[e.ID] = true
+			}
+			if e.Duration < 0 && (!t.allowNegative || e.Type != EventTypeTask) {
+				return nil, fmt.Errorf("duration of events cannot be negative")
+			}
+			if e.Offset < 0 {
+				return nil, fmt.Errorf("offset of events cannot be negative")
+			}
+			if e.Gap < 0 {
+				return nil, fmt.Errorf("gap of events cannot be negative")
+			}
+			if e.Opacity < 0 || e.Opacity > 1 {
+				return nil, fmt.Errorf("opacity of events must be between 0 and 1, got %v", e.Opacity)
+			}
+			if e.SpanRows < 0 {
+				return nil, fmt.Errorf("the rows spanned by an era cannot be negative")
+			}
+			if e.explicitOffset() && !e.Time.IsZero() {
+				return nil, fmt.Errorf(`"Offset" and "Time" cannot be set on the same Event`)
+			}
+			if e.Shape < ShapeRect || e.Shape > ShapeChevron {
+				return nil, fmt.Errorf("unknown event shape %d", e.Shape)
+			}
+			if len(e.Segments) > 0 {
+				if e.Type != EventTypeTask {
+					return nil, fmt.Errorf("only tasks can have segments")
+				}
+				var total time.Duration
+				for _, seg := range e.Segments {
+					if seg.Duration <= 0 {
+						return nil, fmt.Errorf("duration of segments must be positive")
+					}
+					total += seg.Duration
+				}
+				if total != e.Duration {
+					return nil, fmt.Errorf("duration of segments (%v) does not match the event duration (%v)", total, e.Duration)
+				}
+			}
+			if _, ok := patternIDs[e.Pattern]; e.Pattern != "" && !ok {
+				return nil, fmt.Errorf("unknown pattern '%s'", e.Pattern)
+			}
+			duration += max(e.Duration, -e.Duration)
+		}
+	}
+
+	hasTime, err := t.timeMode()
+	if err != nil {
+		return nil, err
+	}
+
+	if duration == 0 {
+		return nil, fmt.Errorf("none of the events has a positive duration")
+	}
+
+	for _, b := range t.bands {
+		if !hasTime {
+			return nil, fmt.Errorf("bands require the events to have their Time set")
+		}
+		if !b.end.After(b.start) {
+			return nil, fmt.Errorf("the end of a band must be after its start")
+		}
+	}
+
+	switch t.fontSizeUnit {
+	case "", "px", "em", "rem":
+	default:
+		return nil, fmt.Errorf("unknown font size unit '%s'", t.fontSizeUnit)
+	}
+
+	markerIDs := make(map[string]bool)
+	for _, m := range t.markerDefs {
+		if err := checkMarkerDef(m.id, m.fragment); err != nil {
+			return nil, err
+		}
+		if markerIDs[m.id] {
+			return nil, fmt.Errorf("duplicate marker %q", m.id)
+		}
+		markerIDs[m.id] = true
+	}
+	for _, id := range t.axisMarkers {
+		if id != "" && !markerIDs[id] {
+			return nil, fmt.Errorf("unknown axis marker %q", id)
+		}
+	}
+
+	if t.fontName != "" {
+		if strings.ContainsAny(t.fontName, `'"\{};<>`) || strings.ContainsFunc(t.fontName, unicode.IsControl) {
+			return nil, fmt.Errorf("invalid embedded font name %q", t.fontName)
+		}
+		if len(t.fontData) == 0 {
+			return nil, fmt.Errorf("the embedded font %q has no data", t.fontName)
+		}
+	}
+
+	for name, val := range t.cssVars {
+		if !validCSSVar.MatchString("--" + strings.TrimPrefix(name, "--")) {
+			return nil, fmt.Errorf("invalid CSS variable name %q", name)
+		}
+		if !validCSSValue(val) {
+			return nil, fmt.Errorf("invalid value %q for the CSS variable %q", val, name)
+		}
+	}
+
+	for class, color := range t.classColors {
+		if !validCSSClass.MatchString(class) {
+			return nil, fmt.Errorf("invalid class name %q", class)
+		}
+		if !validCSSValue(color) {
+			return nil, fmt.Errorf("invalid color %q for the class %q", color, class)
+		}
+	}
+
+	if t.minLabelFontSize <= 0 {
+		return nil, fmt.Errorf("the minimum label font size must be positive, got %d", t.minLabelFontSize)
+	}
+	if t.tickLabelDigits < 0 {
+		return nil, fmt.Errorf("the tick label digits cannot be negative, got %d", t.tickLabelDigits)
+	}
+
+	if _, ok := labelBaselines[t.labelBaseline]; t.labelBaseline != "" && !ok {
+		return nil, fmt.Errorf("unknown label baseline '%s'", t.labelBaseline)
+	}
+
+	if _, ok := unitSuffix(t.tickUnit); t.tickUnitSet && !ok {
+		return nil, fmt.Errorf("unsupported tick unit %v", t.tickUnit)
+	}
+
+	if t.maxDurationOverride < 0 || t.minDurationOverride < 0 {
+		return nil, fmt.Errorf("duration overrides cannot be negative")
+	}
+	if t.maxDurationOverride > 0 && t.minDurationOverride > t.maxDurationOverride {
+		return nil, fmt.Errorf("the minimum duration override cannot be greater than the maximum")
+	}
+
+	// Initialize variables
+	l := &layout{boxes: make(map[string]box)}
+	l.marginTop, l.marginBottom = t.marginTop, t.marginBottom
+	l.tickLabelMargin = labelLineHeight
+	if t.dense {
+		l.marginTop, l.marginBottom = min(t.marginTop, denseMargin), min(t.marginBottom, denseMargin)
+		l.tickLabelMargin = tickFontSize
+	}
+	l.contentTop = l.marginTop
+	l.maxDuration = max(t.MaxDuration(), t.minDurationOverride)
+	if t.maxDurationOverride > 0 {
+		l.maxDuration = t.maxDurationOverride
+	}
+	for _, row := range t.rows {
+		if row.below {
+			l.belowHeight += row.layoutHeight() + t.separatorHeight(row)
+		} else {
+			l.contentHeight += row.layoutHeight() + t.separatorHeight(row)
+		}
+	}
+	l.earliest = t.StartTime()
+	l.timeMode = hasTime
+
+	// Negative durations extend the axis to the left of the start
+	var minStart time.Duration
+	for _, r := range t.rows {
+		minStart = min(minStart, r.minStart(l.earliest))
+	}
+	l.windowOffset = minStart
+	l.maxDuration -= minStart
+
+	// Visible window
+	switch {
+	case !t.timeWindowStart.IsZero() || !t.timeWindowEnd.IsZero():
+		if !hasTime {
+			return nil, fmt.Errorf("a time window requires the events to have their Time set")
+		}
+		if !t.timeWindowEnd.After(t.timeWindowStart) {
+			return nil, fmt.Errorf("the end of the time window must be after its start")
+		}
+		l.windowOffset = t.timeWindowStart.Sub(l.earliest)
+		l.maxDuration = t.timeWindowEnd.Sub(t.timeWindowStart)
+	case t.durationWindowStart != 0 || t.durationWindowEnd != 0:
+		if t.durationWindowEnd <= t.durationWindowStart {
+			return nil, fmt.Errorf("the end of the duration window must be after its start")
+		}
+		l.windowOffset = t.durationWindowStart
+		l.maxDuration = t.durationWindowEnd - t.durationWindowStart
+	}
+	if l.maxDuration <= 0 {
+		return nil, fmt.Errorf("the events span no time on the axis (max duration: %v)", l.maxDuration)
+	}
+
+	if t.eraLabelPosition < EraLabelTop || t.eraLabelPosition > EraLabelBottom {
+		return nil, fmt.Errorf("unknown era label position %d", t.eraLabelPosition)
+	}
+	if t.eraBorderStyle < EraBorderOpenSides || t.eraBorderStyle > EraBorderNone {
+		return nil, fmt.Errorf("unknown era border style %d", t.eraBorderStyle)
+	}
+
+	switch t.dateBoundary {
+	case DateBoundaryNone:
+	case DateBoundaryHour, DateBoundaryDay:
+		if hasTime && l.maxDuration/t.dateBoundary.approx() > maxBoundaryTicks {
+			return nil, fmt.Errorf("too many date boundary ticks for a max duration of %v", l.maxDuration)
+		}
+	default:
+		return nil, fmt.Errorf("unknown date boundary %d", t.dateBoundary)
+	}
+
+	if t.dualAxisLabels && hasTime {
+		l.timeLabelMargin = labelLineHeight
+	}
+	if t.chartTitle != "" {
+		l.titleHeight = titleHeight
+		l.contentTop += l.titleHeight
+	}
+	l.totalHeight = l.contentHeight + l.contentTop + l.marginBottom + t.tickHeight + l.tickLabelMargin + l.timeLabelMargin
+	l.eraBottom = l.totalHeight - l.marginBottom - (t.tickHeight * 3)
+	if bottom := l.contentTop + t.eraRowsBottom(); t.rowOrder != RowOrderBottomUp && l.eraBottom < bottom {
+		// Grow the timeline so every era covers at least its own row
+		l.totalHeight += bottom - l.eraBottom
+		l.eraBottom = bottom
+	}
+	if t.originLabel && hasTime {
+		// Added below the eras so they keep ending at the axis
+		l.originLabelMargin = labelLineHeight
+		l.totalHeight += l.originLabelMargin
+	}
+	if l.belowHeight > 0 {
+		l.totalHeight = t.belowTop(l) + l.belowHeight + l.marginBottom
+	}
+	if t.scale <= 0 {
+		return nil, fmt.Errorf("the scale must be positive, got %v", t.scale)
+	}
+	l.width = scaleLength(t.width, t.scale)
+	if t.padding < 0 {
+		return nil, fmt.Errorf("the viewBox padding cannot be negative, got %v", t.padding)
+	}
+	l.padding = t.padding
+
+	l.contentWidth = min(t.precision, float64(l.maxDuration))
+	if t.contentWidth < 0 {
+		return nil, fmt.Errorf("the content width cannot be negative, got %d", t.contentWidth)
+	}
+	if t.contentWidth > 0 {
+		l.contentWidth = float64(t.contentWidth)
+	}
+	if t.maxWidth > 0 {
+		l.contentWidth = min(l.contentWidth, t.maxWidth-t.marginLeft-t.marginRight)
+	}
+	if t.integerCoords {
+		l.contentWidth = math.Floor(l.contentWidth)
+	}
+	l.totalWidth = l.contentWidth + t.marginLeft + t.marginRight
+	if l.contentWidth <= 0 {
+		return nil, fmt.Errorf("the content width must be positive (precision: %v, max width: %v)", t.precision, t.maxWidth)
+	}
+	if l.totalWidth <= 0 || l.totalHeight <= 0 {
+		return nil, fmt.Errorf("the margins leave no space for the timeline (%vx%v)", l.totalWidth, l.totalHeight)
+	}
+
+	l.numTicks = t.numTicks
+	if t.autoTicks {
+		if t.targetTickSpacing <= 0 {
+			return nil, fmt.Errorf("the target tick spacing must be positive")
+		}
+		l.numTicks = max(int(l.contentWidth)/t.targetTickSpacing, 2)
+	}
+	if t.snapToTicks {
+		// Snap to the drawn ticks, which may be at the date boundaries
+		for _, tick := range t.ticks(l) {
+			l.tickXs = append(l.tickXs, tick.X)
+		}
+	}
+
+	switch t.smallLabelMode {
+	case SmallLabelHide, SmallLabelTruncate:
+	case SmallLabelLeader:
+		// Draw the rows once to find the labels moved to the leader track
+		t.drawRows(l)
+		l.leaderLanes = stackLeaderLabels(l.leaders)
+		lanes := 0
+		for _, lane := range l.leaderLanes {
+			lanes = max(lanes, lane+1)
+		}
+		if lanes > 0 {
+			track := lanes*leaderLaneHeight + leaderGap
+			l.contentTop += track
+			l.eraBottom += track
+			l.totalHeight += track
+		}
+	default:
+		return nil, fmt.Errorf("unknown small event label mode %d", t.smallLabelMode)
+	}
+
+	l.height = t.height
+	if l.height == "" {
+		_, h := l.viewBoxSize()
+		l.height = strconv.FormatFloat(h, 'f', -1, 64)
+	}
+	l.height = scaleLength(l.height, t.scale)
+
+	return l, nil
+}
+
+// Tick is a tick of the timeline axis
+type Tick struct {
+	Index    int           `json:"index"`    // position of the tick from 0 at the start of the axis to the number of ticks at its end
+	Duration time.Duration `json:"duration"` // offset of the tick from the start of the timeline
+	X        float64       `json:"x"`        // x coordinate of the tick in SVG viewBox units
+	Label    string        `json:"label"`    // label drawn below the tick, empty when the tick is not labeled
+
+	timeLabel string // timestamp drawn below Label with SetDualAxisLabels
+}
+
+// IsTimeMode reports whether the events are positioned by their Time instead of by their durations
+//
+// False is returned when the Time is only set on some of the events, which cannot be generated.
+func (t *Timeline) IsTimeMode() bool {
+	timeMode, err := t.timeMode()
+	return timeMode && err == nil
+}
+
+// timeMode reports whether any event has its Time set, which is then required on all of them
+func (t *Timeline) timeMode() (bool, error) {
+	var hasTime, hasNoTime bool
+	for _, r := range t.rows {
+		for _, e := range r.events {
+			if e.Time.IsZero() {
+				hasNoTime = true
+			} else {
+				hasTime = true
+			}
+		}
+	}
+	if hasTime && hasNoTime {
+		return false, fmt.Errorf(`when "Time" is set on any Event, it must be set on all of them`)
+	}
+	return hasTime, nil
+}
+
+// Ticks returns the ticks of the axis as drawn by Generate
+//
+// Nil is returned when the timeline cannot be generated.
+func (t *Timeline) Ticks() []Tick {
+	l, err := t.setup()
+	if err != nil {
+		return nil
+	}
+	return t.ticks(l)
+}
+
+// ticks computes the ticks of the axis and their labels
+func (t *Timeline) ticks(l *layout) []Tick {
+	if l.timeMode && t.dateBoundary != DateBoundaryNone {
+		return t.boundaryTicks(l)
+	}
+	if l.numTicks <= 0 || l.maxDuration <= 0 {
+		return nil
+	}
+	tickDuration := l.maxDuration / time.Duration(l.numTicks)
+
+	durations := make([]time.Duration, 0, l.numTicks+1)
+	for i := 0; i <= l.numTicks; i++ {
+		durations = append(durations, l.windowOffset+tickDuration*time.Duration(i))
+	}
+	labels := t.tickLabels(durations)
+	timeLayout := "15:04:05"
+	if tickDuration < time.Second {
+		timeLayout = "15:04:05.000"
+	}
+	if start, end := l.earliest.Add(l.windowOffset), l.earliest.Add(l.windowOffset+l.maxDuration); start.Format(time.DateOnly) != end.Format(time.DateOnly) {
+		// Ticks on different days would share the same labels otherwise
+		timeLayout = time.DateOnly + " " + timeLayout
+	}
+
+	ticks := make([]Tick, 0, len(durations))
+	lastLabelEnd := math.Inf(-1)
+	for i, d := range durations {
+		currentDuration := tickDuration * time.Duration(i)
+		tick := Tick{
+			Index:    i,
+			Duration: d,
+			X:        t.coord(float64(t.marginLeft) + float64(l.contentWidth)*float64(currentDuration)/float64(l.maxDuration)),
+		}
+		ticks = append(ticks, tick)
+		if t.endLabelsOnly && i != 0 && i != l.numTicks {
+			continue
+		}
+
+		label := labels[i]
+		var timeLabel string
+		if l.timeLabelMargin > 0 {
+			label = "T+" + label
+			timeLabel = l.earliest.Add(d).Format(timeLayout)
+		}
+		if t.tickLabelSkipOverlap {
+			labelWidth := t.tickLabelWidth(label, timeLabel)
+			if tick.X-labelWidth/2 < lastLabelEnd {
+				continue
+			}
+			lastLabelEnd = tick.X + labelWidth/2
+		}
+		ticks[i].Label, ticks[i].timeLabel = label, timeLabel
+	}
+	return ticks
+}
+
+// boundaryTicks computes the ticks of the axis at the date boundaries within the visible window
+func (t *Timeline) boundaryTicks(l *layout) []Tick {
+	start := l.earliest.Add(l.windowOffset)
+	end := start.Add(l.maxDuration)
+
+	durations := []time.Duration{0}
+	labels := []string{""}
+	for b := t.dateBoundary.first(start); !b.After(end); b = t.dateBoundary.next(b) {
+		d := b.Sub(start)
+		if d == 0 {
+			labels[0] = t.dateBoundary.label(b)
+			continue
+		}
+		durations = append(durations, d)
+		labels = append(labels, t.dateBoundary.label(b))
+	}
+	if durations[len(durations)-1] != l.maxDuration {
+		durations = append(durations, l.maxDuration)
+		labels = append(labels, "")
+	}
+
+	ticks := make([]Tick, 0, len(durations))
+	lastLabelEnd := math.Inf(-1)
+	for i, d := range durations {
+		ticks = append(ticks, Tick{
+			Index:    i,
+			Duration: l.windowOffset + d,
+			X:        t.coord(t.marginLeft + l.contentWidth*float64(d)/float64(l.maxDuration)),
+		})
+		if labels[i] == "" {
+			continue
+		}
+		if t.tickLabelSkipOverlap {
+			labelWidth := t.tickLabelWidth(labels[i], "")
+			if ticks[i].X-labelWidth/2 < lastLabelEnd {
+				continue
+			}
+			lastLabelEnd = ticks[i].X + labelWidth/2
+		}
+		ticks[i].Label = labels[i]
+	}
+	return ticks
+}
+
+// approx returns the usual length of the boundary unit
+func (b DateBoundary) approx() time.Duration {
+	if b == DateBoundaryDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// first returns the first boundary at or after the time
+func (b DateBoundary) first(t time.Time) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	if b == DateBoundaryHour {
+		first = time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location())
+	}
+	if first.Before(t) {
+		first = b.next(first)
+	}
+	return first
+}
+
+// next returns the boundary following the given one
+func (b DateBoundary) next(t time.Time) time.Time {
+	if b == DateBoundaryHour {
+		// Absolute hours keep hitting the hour boundaries across DST changes
+		return t.Add(time.Hour)
+	}
+	y, m, d := t.Date()
+	next := time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+	if !next.After(t) {
+		// Midnight skipped by a DST change resolved to an earlier time
+		next = t.Add(24 * time.Hour)
+	}
+	return next
+}
+
+// label formats the tick label of a boundary
+func (b DateBoundary) label(t time.Time) string {
+	if b == DateBoundaryHour && (t.Hour() != 0 || t.Minute() != 0) {
+		return t.Format("15:04")
+	}
+	return t.Format("2006-01-02")
+}
+
+// tickLabelWidth approximates the width of the widest label of a tick
+func (t *Timeline) tickLabelWidth(label, timeLabel string) float64 {
+	return max(t.textWidth(label, tickFontSize), t.textWidth(timeLabel, tickFontSize))
+}
+
+// drawRows draws the events of all rows and returns their elements sorted by ZIndex
+func (t *Timeline) drawRows(l *layout) []any {
+	type drawnEvent struct {
+		zIndex  int
+		element any
+	}
+	n := t.eventCount()
+	events := svg{Elements: make([]any, 0, n)}
+	drawn := make([]drawnEvent, 0, n)
+	l.boxes = make(map[string]box, n)
+	l.events = make([]EventLayout, 0, n)
+	l.eraLabels, l.leaders = nil, nil
+	currentY, belowY := l.contentTop, t.belowTop(l)
+	for i, row := range t.rows {
+		currentDuration := row.startOffset
+		rowY := currentY
+		switch {
+		case row.below:
+			rowY = belowY
+			belowY += row.layoutHeight() + t.separatorHeight(row)
+		case t.rowOrder == RowOrderBottomUp:
+			// Mirror the row within the content area
+			rowY = 2*l.contentTop + l.contentHeight - currentY - row.layoutHeight()
+		}
+
+		// Draw events, wrapping consecutive events of the same group
+		var wrapper *g
+		for _, event := range row.events {
+			if event.explicitOffset() {
+				event.Offset += row.startOffset
+			}
+			n := len(events.Elements)
+			spanHeight := t.spanHeight(i, event.SpanRows)
+			if row.below && spanHeight == 0 {
+				// There is no axis to span down to
+				spanHeight = row.layoutHeight()
+			}
+			currentDuration = t.drawEvent(l, &events, event, rowY, row.layoutHeight(), spanHeight, currentDuration)
+			if event.Group == "" {
+				wrapper = nil
+				for _, el := range events.Elements[n:] {
+					drawn = append(drawn, drawnEvent{zIndex: event.ZIndex, element: el})
+				}
+				continue
+			}
+			if len(events.Elements) == n {
+				continue
+			}
+			if wrapper == nil || wrapper.DataGroup != event.Group {
+				wrapper = &g{Class: "tl-group", DataGroup: event.Group}
+				drawn = append(drawn, drawnEvent{zIndex: event.ZIndex, element: wrapper})
+			}
+			wrapper.Elements = append(wrapper.Elements, events.Elements[n:]...)
+		}
+
+		if !row.below {
+			currentY += row.layoutHeight() + t.separatorHeight(row)
+		}
+	}
+	slices.SortStableFunc(drawn, func(a, b drawnEvent) int {
+		return a.zIndex - b.zIndex
+	})
+
+	elements := make([]any, 0, len(drawn))
+	for _, d := range drawn {
+		elements = append(elements, d.element)
+	}
+	return elements
+}
+
+// drawBadge draws a badge in the top-right corner at (right, top) of an event of the given width
+//
+// The badge is skipped when it would cover too much of the event.
+func (t *Timeline) drawBadge(group *g, badge string, right, top, width float64, rowHeight int) {
+	fontSize := min(rowHeight/3, 10)
+	if fontSize < 4 {
+		return
+	}
+	textWidth := t.textWidth(badge, float64(fontSize))
+	r := max(float64(fontSize)*0.8, textWidth/2+2)
+	if width < 4*r {
+		return
+	}
+	cx, cy := t.coord(right-r-1), t.coord(top+r+1)
+	group.Elements = append(group.Elements,
+		g{Class: "tl-badge", Elements: []any{
+			circle{Cx: cx, Cy: cy, R: r},
+			text{X: cx, Y: cy, FontSize: t.fontSize(fontSize), FontFamily: t.fontFamily(), DominantBaseline: "central", TextAnchor: "middle", Content: badge},
+		}},
+	)
+}
+
+// separatorHeight returns the space below a row, none in dense mode
+func (t *Timeline) separatorHeight(r *Row) int {
+	if t.dense {
+		return 0
+	}
+	return r.separatorHeight
+}
+
+// spanHeight returns the height of n rows starting at the row index including the separators between them
+//
+// 0 is returned when n is not positive or reaches past the last row.
+func (t *Timeline) spanHeight(i, n int) int {
+	if n <= 0 || i+n >= len(t.rows) {
+		return 0
+	}
+	height := 0
+	for _, row := range t.rows[i : i+n] {
+		height += row.layoutHeight() + t.separatorHeight(row)
+	}
+	return height - t.separatorHeight(t.rows[i+n-1])
+}
+
+// belowTop returns the Y coordinate where the rows below the axis start, under the tick labels
+func (t *Timeline) belowTop(l *layout) int {
+	timelineY := l.contentTop + l.contentHeight + t.tickHeight
+	return timelineY + 2*t.tickHeight + l.tickLabelMargin + l.timeLabelMargin + l.originLabelMargin
+}
+
+// eraRowsBottom returns the offset from the top of the content to the bottom of
+// the lowest row holding an era that spans down to the axis
+func (t *Timeline) eraRowsBottom() int {
+	bottom, currentY := 0, 0
+	for i, row := range t.rows {
+		if row.below {
+			continue
+		}
+		for _, event := range row.events {
+			if event.Type == EventTypeEra && t.spanHeight(i, event.SpanRows) == 0 {
+				bottom = currentY + row.layoutHeight()
+				break
+			}
+		}
+		currentY += row.layoutHeight() + t.separatorHeight(row)
+	}
+	return bottom
+}
+
+// drawBand draws a band behind the rows clipped to the content width
+func (t *Timeline) drawBand(l *layout, root *svg, b band) {
+	start := max(b.start.Sub(l.earliest)-l.windowOffset, 0)
+	end := min(b.end.Sub(l.earliest)-l.windowOffset, l.maxDuration)
+	if end <= start {
+		return
+	}
+
+	class := "tl-band"
+	if b.class != "" {
+		class += " " + b.class
+	}
+	startX := t.marginLeft + l.contentWidth*float64(start)/float64(l.maxDuration)
+	width := l.contentWidth * float64(end-start) / float64(l.maxDuration)
+	if t.integerCoords {
+		width = math.Round(startX+width) - math.Round(startX)
+		startX = math.Round(startX)
+	}
+	root.Elements = append(root.Elements,
+		rect{Class: class, X: startX, Y: float64(l.contentTop), Width: width, Height: float64(l.contentHeight + t.tickHeight)},
+	)
+}
+
+// drawEvent draws an event in the timeline
+//
+// spanHeight is the height covered by an era spanning a subset of the rows, 0 spans all of them.
+func (t *Timeline) drawEvent(l *layout, root *svg, event Event, currentY, rowHeight, spanHeight int, currentDuration time.Duration) time.Duration {
+	if !l.earliest.IsZero() {
+		currentDuration = event.Time.Sub(l.earliest)
+	} else if event.explicitOffset() {
+		currentDuration = event.Offset
+	} else {
+		currentDuration += event.Gap
+	}
+
+	// Events exceeding the visible window are clipped at the content edges
+	visibleStart := currentDuration - l.windowOffset
+	visibleEnd := visibleStart + event.Duration
+	negative := event.Duration < 0
+	if negative {
+		visibleStart, visibleEnd = visibleEnd, visibleStart
+	}
+	if event.Hidden || visibleStart >= l.maxDuration || visibleEnd <= 0 {
+		if l.earliest.IsZero() {
+			currentDuration += event.Duration
+		}
+		return currentDuration
+	}
+	clippedLeft := visibleStart < 0
+	clippedRight := visibleEnd > l.maxDuration
+	visibleStart = max(visibleStart, 0)
+	visibleEnd = min(visibleEnd, l.maxDuration)
+	visibleDuration := visibleEnd - visibleStart
+	clipped := clippedLeft || clippedRight
+
+	startX := t.xAt(l, visibleStart)
+	eventWidth := l.contentWidth * float64(visibleDuration) / float64(l.maxDuration)
+	if t.snapToTicks {
+		endX := t.snapX(l, startX+eventWidth)
+		startX = t.snapX(l, startX)
+		eventWidth = endX - startX
+	}
+	if t.integerCoords {
+		// Round both edges so adjacent events still share them
+		endX := math.Round(startX + eventWidth)
+		startX = math.Round(startX)
+		eventWidth = endX - startX
+	}
+
+	var height int
+	var strokeDashArray string
+	var textYOffset float64
+	var pos EraLabelPosition // placement of the era label after mirroring
+
+	if event.Type == EventTypeEra {
+		pos = t.eraLabelPosition
+		if t.rowOrder == RowOrderBottomUp {
+			// Span from the top of the timeline down to the bottom of the era row
+			height = currentY + rowHeight - l.contentTop
+			if spanHeight > 0 {
+				height = spanHeight
+			}
+			currentY += rowHeight - height
+			switch pos {
+			case EraLabelTop:
+				pos = EraLabelBottom
+			case EraLabelBottom:
+				pos = EraLabelTop
+			}
+		} else if spanHeight > 0 {
+			height = spanHeight
+		} else {
+			height = l.eraBottom - currentY
+		}
+		if t.eraBorderStyle == EraBorderOpenSides {
+			// Skip the top and bottom sides of the rect
+			strokeDashArray = "0," + strconv.FormatFloat(eventWidth, 'f', 6, 64) + "," + strconv.Itoa(height) + ",0"
+		}
+		switch pos {
+		case EraLabelCenter:
+			textYOffset = float64(height) / 2
+		case EraLabelBottom:
+			textYOffset = float64(height) - float64(rowHeight)/3
+		default:
+			textYOffset = float64(rowHeight) / 3
+		}
+	} else {
+		height = rowHeight
+		textYOffset = float64(rowHeight) / 2
+	}
+	if event.ID != "" {
+		l.boxes[event.ID] = box{x: startX, y: float64(currentY), w: eventWidth, h: float64(height)}
+	}
+
+	class := "tl-event"
+	if event.Type == EventTypeEra {
+		class = "tl-era"
+	}
+	if negative {
+		class += " tl-negative"
+	}
+	if clipped {
+		class += " tl-clipped"
+	}
+	if event.Outline {
+		class += " tl-outline"
+	}
+	if event.Class != "" {
+		class += " " + event.Class
+	}
+
+	l.events = append(l.events, EventLayout{ID: event.ID, Class: class, X: startX, Y: float64(currentY), Width: eventWidth, Height: float64(height), Label: event.Text})
+
+	group := g{ID: event.ID, Class: class}
+
+	// Title
+	if t.interactive {
+		group.DataTooltip = t.interactiveTooltip(l, event)
+	} else if tooltip := t.tooltip(l, event); tooltip != "" {
+		group.Elements = append(group.Elements,
+			title{Content: tooltip},
+		)
+	}
+
+	// Shape
+	var style, textStyle string
+	switch {
+	case event.Outline: // styled by the "tl-outline" class
+	case event.Pattern != "":
+		style = "fill: url(#" + patternIDs[event.Pattern] + ")"
+	case event.Fill != "":
+		style = "fill: " + event.Fill
+		if color, ok := contrastText(event.Fill); t.autoTextContrast && ok {
+			textStyle = "fill: " + color
+		}
+	}
+	if event.Type == EventTypeEra && t.eraBorderStyle == EraBorderNone {
+		// Inlined to take precedence over the stroke of the CSS style
+		style = strings.TrimPrefix(style+"; stroke: none", "; ")
+	}
+	shape := ShapeRect
+	if event.Type == EventTypeTask {
+		shape = event.Shape
+	}
+	var chevronTip float64
+	switch {
+	case len(event.Segments) > 0:
+		t.drawSegments(l, &group, event, currentDuration-l.windowOffset, currentY, height)
+	case shape == ShapeChevron:
+		chevronTip = t.coord(min(float64(height)/2, eventWidth/2))
+		group.Elements = append(group.Elements,
+			polygon{Points: chevronPoints(startX, float64(currentY), eventWidth, float64(height), chevronTip), FillOpacity: event.Opacity, Style: style},
+		)
+	case shape == ShapeRounded:
+		radius := min(float64(height)/4, eventWidth/2)
+		group.Elements = append(group.Elements,
+			rect{X: startX, Y: float64(currentY), Width: eventWidth, Height: float64(height), Rx: radius, Ry: radius, FillOpacity: event.Opacity, Style: style},
+		)
+	default:
+		group.Elements = append(group.Elements,
+			rect{X: startX, Y: float64(currentY), Width: eventWidth, Height: float64(height), FillOpacity: event.Opacity, StrokeDasharray: strokeDashArray, Style: style},
+		)
+	}
+
+	// Clip marks at the content edges
+	for _, edge := range []struct {
+		clipped bool
+		x       float64
+	}{{clippedLeft, startX}, {clippedRight, startX + eventWidth}} {
+		if edge.clipped {
+			group.Elements = append(group.Elements,
+				line{Class: "tl-clip-mark", X1: edge.x, Y1: float64(currentY), X2: edge.x, Y2: float64(currentY + height), StrokeWidth: 2, StrokeDasharray: "3,2"},
+			)
+		}
+	}
+
+	if len(event.Annotations) > 0 {
+		t.drawAnnotations(l, &group, event, currentDuration-l.windowOffset, currentY, height)
+	}
+
+	// Text
+	if event.Text != "" {
+		textSize := t.labelSize(event.Text, eventWidth-chevronTip, rowHeight)
+		if event.Type == EventTypeEra {
+			textSize -= 1
+		}
+		content := event.Text
+		switch {
+		case textSize >= t.minLabelFontSize:
+		case event.LabelAlways:
+			textSize = t.minLabelFontSize
+		case t.smallLabelMode == SmallLabelTruncate:
+			textSize = t.minLabelFontSize
+			content = t.truncateLabel(content, eventWidth-chevronTip, float64(textSize))
+		case t.smallLabelMode == SmallLabelLeader:
+			t.drawLeaderLabel(l, &group, content, t.coord(startX+eventWidth/2), float64(currentY))
+		}
+		if textSize >= t.minLabelFontSize && content != "" {
+			textWidth := t.textWidth(content, float64(textSize))
+			textX, textAnchor := t.labelPosition(l, startX+eventWidth/2, textWidth)
+			var textClass string
+			if event.LabelAlways && textWidth > eventWidth-chevronTip {
+				// Draw the label next to the event since it overflows it, on the
+				// left when there is no room before the right content edge
+				textX, textAnchor = startX+eventWidth+labelPadding, "start"
+				if textX+textWidth > t.marginLeft+l.contentWidth {
+					textX, textAnchor = startX-labelPadding, "end"
+				}
+				textClass, textStyle = "tl-label-outside", ""
+			}
+			textY := float64(currentY) + textYOffset
+			baseline := "middle"
+			if event.Type == EventTypeTask && t.labelBaseline != "" {
+				baseline = t.labelBaseline
+				switch labelBaselines[baseline] {
+				case -1:
+					textY = float64(currentY + labelPadding)
+				case 1:
+					textY = float64(currentY + rowHeight - labelPadding)
+				}
+			}
+			if event.Type == EventTypeEra && t.eraLabelStacking {
+				lines := strings.Count(content, "\n") + 1
+				textY = l.stackEraLabel(startX, textY, float64(textSize*lines), pos == EraLabelBottom)
+			}
+
+			group.Elements = append(group.Elements,
+				textLines(text{Class: textClass, X: t.coord(textX), Y: t.coord(textY), FontSize: t.fontSize(textSize), FontFamily: t.fontFamily(), DominantBaseline: baseline, TextAnchor: textAnchor, Style: textStyle, Content: content}),
+			)
+		}
+	}
+
+	// Badge
+	if event.Badge != "" {
+		t.drawBadge(&group, event.Badge, startX+eventWidth-chevronTip, float64(currentY), eventWidth-chevronTip, rowHeight)
+	}
+
+	if t.eventDecorator != nil {
+		t.eventDecorator(event, &group)
+	}
+
+	if event.URL != "" {
+		root.Elements = append(root.Elements, anchor{Href: event.URL, Elements: []any{group}})
+	} else {
+		root.Elements = append(root.Elements, group)
+	}
+
+	if l.earliest.IsZero() {
+		currentDuration += event.Duration
+	}
+
+	return currentDuration
+}
+
+// truncateLabel cuts the lines of a label with an ellipsis so they fit the width
+//
+// An empty string is returned when not even a single character of a line fits.
+func (t *Timeline) truncateLabel(s string, width, fontSize float64) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		runes := []rune(line)
+		for len(runes) > 0 && t.textWidth(line, fontSize) > width {
+			runes = runes[:len(runes)-1]
+			line = string(runes) + "…"
+		}
+		if len(runes) == 0 && lines[i] != "" {
+			return ""
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// drawLeaderLabel draws a label in the leader track connected by a line to the top of its event at x
+//
+// The labels are only collected until setup has stacked them in the lanes of the track.
+func (t *Timeline) drawLeaderLabel(l *layout, group *g, label string, x, eventY float64) {
+	label = strings.ReplaceAll(label, "\n", " ")
+	width := t.textWidth(label, leaderFontSize)
+	textX, anchor := t.labelPosition(l, x, width)
+	start := textX - width/2
+	switch anchor {
+	case "start":
+		start = textX
+	case "end":
+		start = textX - width
+	}
+	i := len(l.leaders)
+	l.leaders = append(l.leaders, leaderLabel{start: start, end: start + width})
+	if i >= len(l.leaderLanes) {
+		return
+	}
+
+	laneBottom := float64(l.contentTop - leaderGap - l.leaderLanes[i]*leaderLaneHeight)
+	group.Elements = append(group.Elements,
+		line{Class: "tl-leader", X1: x, Y1: laneBottom, X2: x, Y2: eventY},
+		text{Class: "tl-label-outside", X: textX, Y: laneBottom - leaderLaneHeight/2, FontSize: t.fontSize(leaderFontSize), FontFamily: t.fontFamily(), DominantBaseline: "middle", TextAnchor: anchor, Content: label},
+	)
+}
+
+// stackLeaderLabels returns the lowest lane of the leader track where each label
+// does not overlap the labels placed before it from left to right
+func stackLeaderLabels(labels []leaderLabel) []int {
+	const spacing = 4 // minimum distance in pixels between labels of the same lane
+	order := make([]int, len(labels))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(labels[a].start, labels[b].start)
+	})
+
+	lanes := make([]int, len(labels))
+	var laneEnds []float64 // end of the last label of each lane
+	for _, i := range order {
+		lane := slices.IndexFunc(laneEnds, func(end float64) bool {
+			return end+spacing <= labels[i].start
+		})
+		if lane < 0 {
+			lane = len(laneEnds)
+			laneEnds = append(laneEnds, 0)
+		}
+		laneEnds[lane] = labels[i].end
+		lanes[i] = lane
+	}
+	return lanes
+}
+
+// tickLabels formats the tick durations increasing the rounding digits when needed
+// so that adjacent ticks with different durations never share the same label
+func (t *Timeline) tickLabels(durations []time.Duration) []string {
+	const maxDigits = 9
+	labels := make([]string, len(durations))
+	for digits := t.tickLabelDigits; ; digits++ {
+		distinct := true
+		for i, d := range durations {
+			if t.tickUnitSet {
+				labels[i] = formatFixedUnit(d, t.tickUnit, digits, t.numberLocale)
+			} else {
+				labels[i] = formatDurationStyle(d, t.durationFormat, digits, t.numberLocale)
+			}
+			if i > 0 && d != durations[i-1] && labels[i] == labels[i-1] {
+				distinct = false
+			}
+		}
+		if distinct || digits >= maxDigits {
+			return labels
+		}
+	}
+}
+
+// tooltip returns the tooltip text of an event
+func (t *Timeline) tooltip(l *layout, event Event) string {
+	if !t.tooltipDuration {
+		return event.Title
+	}
+
+	const timeLayout = "2006-01-02 15:04:05.999999999"
+	lines := make([]string, 0, 4)
+	if event.Title != "" {
+		lines = append(lines, event.Title)
+	}
+	lines = append(lines, "duration: "+formatDurationStyle(event.Duration, t.durationFormat, 2, t.numberLocale))
+	if !l.earliest.IsZero() {
+		lines = append(lines,
+			"start: "+event.Time.Format(timeLayout),
+			"end: "+event.Time.Add(event.Duration).Format(timeLayout),
+		)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// interactiveTooltip returns the text of the custom tooltip of an event
+func (t *Timeline) interactiveTooltip(l *layout, event Event) string {
+	lines := make([]string, 0, 5)
+	if event.Text != "" {
+		lines = append(lines, event.Text)
+	}
+	if tooltip := t.tooltip(l, event); tooltip != "" {
+		lines = append(lines, tooltip)
+	}
+	if !t.tooltipDuration {
+		lines = append(lines, "duration: "+formatDurationStyle(event.Duration, t.durationFormat, 2, t.numberLocale))
+	}
+	return strings.Join(lines, "\n")
 }
 
-// SetTickHeight sets the height of the timeline ticks
-func (t *Timeline) SetTickHeight(h int) {
-	t.tickHeight = h
+// fontSize returns the font-size attribute of a label of the given size in pixels
+func (t *Timeline) fontSize(px int) string {
+	const base = 16
+	if t.fontSizeUnit == "em" || t.fontSizeUnit == "rem" {
+		return strconv.FormatFloat(float64(px)/base, 'f', -1, 64) + t.fontSizeUnit
+	}
+	return strconv.Itoa(px)
 }
 
-// SetMargins sets the margins of the timeline inside of the SVG
-func (t *Timeline) SetMargins(top, right, bottom, left int) {
-	t.marginTop = top
-	t.marginBottom = bottom
-	t.marginLeft = float64(left)
-	t.marginRight = float64(right)
+// fontFamily returns the font family of the labels
+func (t *Timeline) fontFamily() string {
+	if t.fontName == "" {
+		return "monospace"
+	}
+	return "'" + t.fontName + "', monospace"
 }
 
-// SetStyle sets the CSS style for the timeline (for reference use the value of DefaultStyle)
-func (t *Timeline) SetStyle(s string) {
-	t.style = s
+// fontFaceRule returns the @font-face rule of the embedded font
+func (t *Timeline) fontFaceRule() string {
+	if t.fontName == "" {
+		return ""
+	}
+	return "@font-face {\n" +
+		"  font-family: '" + t.fontName + "';\n" +
+		"  src: url(data:font/woff2;base64," + base64.StdEncoding.EncodeToString(t.fontData) + ") format('woff2');\n" +
+		"}\n\n"
 }
 
-// AddRow adds a new row to the timeline
-func (t *Timeline) AddRow(height int, separatorHeight int) *Row {
-	row := &Row{
-		height:          height,
-		separatorHeight: separatorHeight,
-		events:          make([]Event, 0),
+// targetRule returns the CSS rule highlighting the event targeted by the URL fragment
+func (t *Timeline) targetRule() string {
+	if !t.targetHighlight {
+		return ""
 	}
-	t.rows = append(t.rows, row)
-	return row
+	return "\n.tl-event:target rect,\n.tl-event:target polygon,\n.tl-era:target rect {\n" +
+		"  stroke: var(--tl-target-stroke, #ff8800);\n" +
+		"  stroke-width: 3;\n" +
+		"}\n"
 }
 
-// GetRows returns the timeline rows
-func (t *Timeline) GetRows() []*Row {
-	return t.rows
+// tooltipRule returns the CSS rules of the interactive tooltip
+func (t *Timeline) tooltipRule() string {
+	if !t.interactive {
+		return ""
+	}
+	return "\n.tl-tooltip {\n  display: none;\n  pointer-events: none;\n}\n" +
+		"\n.tl-tooltip rect {\n  fill: var(--tl-tooltip-fill, rgba(0, 0, 0, 0.85));\n}\n" +
+		"\n.tl-tooltip text {\n  fill: var(--tl-tooltip-text, #ffffff);\n}\n"
 }
 
-// GetRowByIndex returns the row at the index or nil if not found
-func (t *Timeline) GetRowByIndex(i int) *Row {
-	if i >= len(t.rows) {
-		return nil
-	}
-	return t.rows[i]
+// validCSSValue reports whether a value can be written in a declaration without ending it or its rule
+func validCSSValue(val string) bool {
+	return !strings.ContainsAny(val, ";{}") && !strings.ContainsFunc(val, unicode.IsControl)
 }
 
-// GetLastRow returns the last row
-func (t *Timeline) GetLastRow() *Row {
-	if len(t.rows) == 0 {
-		return nil
+// cssVarsRule returns the :root rule declaring the custom CSS properties sorted by name
+func (t *Timeline) cssVarsRule() string {
+	if len(t.cssVars) == 0 {
+		return ""
 	}
-	return t.rows[len(t.rows)-1]
+	names := make([]string, 0, len(t.cssVars))
+	for name := range t.cssVars {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var sb strings.Builder
+	sb.WriteString(":root {\n")
+	for _, name := range names {
+		sb.WriteString("  --" + strings.TrimPrefix(name, "--") + ": " + t.cssVars[name] + ";\n")
+	}
+	sb.WriteString("}\n\n")
+	return sb.String()
 }
 
-// MaxDuration returns the maximum duration across all rows
-func (t *Timeline) MaxDuration() time.Duration {
-	var m time.Duration
-	for _, row := range t.rows {
-		duration := row.TotalDuration(t.StartTime())
-		if duration > m {
-			m = duration
-		}
+// classColorsRule returns the fill rules of the classes set with SetClassColors sorted by name
+func (t *Timeline) classColorsRule() string {
+	if len(t.classColors) == 0 {
+		return ""
 	}
-	return m
+	classes := make([]string, 0, len(t.classColors))
+	for class := range t.classColors {
+		classes = append(classes, class)
+	}
+	slices.Sort(classes)
+
+	var sb strings.Builder
+	for _, class := range classes {
+		sb.WriteString("\n." + class + " rect,\n." + class + " polygon {\n")
+		sb.WriteString("  fill: " + t.classColors[class] + ";\n}\n")
+	}
+	return sb.String()
 }
 
-// TotalRowHeight calculates the total height of all rows including separators
-func (t *Timeline) TotalRowHeight() int {
-	total := 0
-	for _, row := range t.rows {
-		total += row.height + row.separatorHeight
+// chevronPoints returns the polygon points of a chevron pointing to the right
+func chevronPoints(x, y, width, height, tip float64) string {
+	points := [][2]float64{
+		{x, y},
+		{x + width - tip, y},
+		{x + width, y + height/2},
+		{x + width - tip, y + height},
+		{x, y + height},
+		{x + tip, y + height/2},
 	}
-	return total
+	parts := make([]string, 0, len(points))
+	for _, p := range points {
+		parts = append(parts, strconv.FormatFloat(p[0], 'f', -1, 64)+","+strconv.FormatFloat(p[1], 'f', -1, 64))
+	}
+	return strings.Join(parts, " ")
 }
 
-// StartTime returns the earliest time that is currently set on the timeline
-// given the existing rows and events
-func (t *Timeline) StartTime() time.Time {
-	var earliest time.Time
+// eventCount returns the number of events across all rows
+func (t *Timeline) eventCount() int {
+	n := 0
 	for _, r := range t.rows {
-		rowStartTime := r.StartTime()
-		if earliest.IsZero() || rowStartTime.Before(earliest) {
-			earliest = rowStartTime
-		}
+		n += len(r.events)
 	}
-	return earliest
+	return n
 }
 
-// EndTime returns the latest time that is currently set on the timeline
-// given the added rows and events (including their durations)
-func (t *Timeline) EndTime() time.Time {
-	var end time.Time
+// usesLinks reports whether any event links to an URL
+func (t *Timeline) usesLinks() bool {
 	for _, r := range t.rows {
-		rowEndTime := r.EndTime()
-		if end.IsZero() || rowEndTime.After(end) {
-			end = rowEndTime
+		for _, e := range r.events {
+			if e.URL != "" {
+				return true
+			}
 		}
 	}
-	return end
+	return false
 }
 
-// Generate generates the timeline SVG with the current configuration
-func (t *Timeline) Generate() (string, error) {
-	err := t.setup()
-	if err != nil {
-		return "", err
-	}
-
-	root := svg{
-		Xmlns:               "http://www.w3.org/2000/svg",
-		ID:                  t.id,
-		Width:               t.width,
-		Height:              t.height,
-		ViewBox:             fmt.Sprintf("0 0 %f %f", t.totalWidth, float64(t.totalHeight)),
-		PreserveAspectRatio: "xMinYMin meet",
+// usesPatterns reports whether any event is filled with a pattern
+func (t *Timeline) usesPatterns() bool {
+	for _, r := range t.rows {
+		for _, e := range r.events {
+			if e.Pattern != "" {
+				return true
+			}
+		}
 	}
+	return false
+}
 
-	// Definitions
-	defs := svgDefs{}
-	if t.style != "" {
-		defs.Elements = append(defs.Elements, svgStyle{Content: t.style})
+// checkMarkerDef checks that a marker fragment is well-formed XML with a single
+// <marker> root element carrying the given id
+func checkMarkerDef(id, fragment string) error {
+	if !validID.MatchString(id) {
+		return fmt.Errorf("invalid marker id %q: must start with a letter and contain no spaces", id)
 	}
-	root.Elements = append(root.Elements, defs)
-
-	// Background
-	root.Elements = append(root.Elements,
-		rect{Class: "tl-bg", X: 0, Y: 0, Width: t.totalWidth, Height: float64(t.totalHeight), Fill: "none"},
-	)
-
-	// Draw rows
-	currentY := t.marginTop
-	for _, row := range t.rows {
-		if t.maxDuration <= 0 {
+	dec := xml.NewDecoder(strings.NewReader(fragment))
+	depth, roots := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
 			break
 		}
-		var currentDuration time.Duration
-
-		// Draw events
-		for _, event := range row.events {
-			currentDuration = t.drawEvent(&root, event, currentY, row.height, currentDuration)
+		if err != nil {
+			return fmt.Errorf("marker %q is not well-formed XML: %v", id, err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+				if tok.Name.Local != "marker" {
+					return fmt.Errorf("marker %q must be a <marker> element, got <%s>", id, tok.Name.Local)
+				}
+				var markerID string
+				for _, attr := range tok.Attr {
+					if attr.Name.Local == "id" {
+						markerID = attr.Value
+					}
+				}
+				if markerID != id {
+					return fmt.Errorf("marker %q has a mismatching id %q", id, markerID)
+				}
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(tok)) > 0 {
+				return fmt.Errorf("marker %q has text outside of the <marker> element", id)
+			}
 		}
-
-		currentY += row.height + row.separatorHeight
 	}
+	if roots != 1 {
+		return fmt.Errorf("marker %q must contain exactly one <marker> element", id)
+	}
+	return nil
+}
 
-	// Draw timeline axis
-	timelineY := t.marginTop + t.contentHeight + t.tickHeight
-	root.Elements = append(root.Elements,
-		line{Class: "tl-axis", X1: t.marginLeft, Y1: float64(timelineY), X2: t.marginLeft + t.contentWidth, Y2: float64(timelineY)},
-	)
-
-	// Draw tick marks and labels
-	group := g{Class: "tl-ticks"}
-	if t.numTicks > 0 && t.maxDuration > 0 {
-		tickDuration := t.maxDuration / time.Duration(t.numTicks)
-
-		for i := 0; i <= t.numTicks; i++ {
-			currentDuration := tickDuration * time.Duration(i)
-			x := float64(t.marginLeft) + float64(t.contentWidth)*float64(currentDuration)/float64(t.maxDuration)
+// drawSegments draws the segments of a task starting at the given offset of the visible window
+func (t *Timeline) drawSegments(l *layout, group *g, event Event, start time.Duration, y, height int) {
+	for _, seg := range event.Segments {
+		segStart, segEnd := max(start, 0), min(start+seg.Duration, l.maxDuration)
+		start += seg.Duration
+		if segEnd <= segStart {
+			continue
+		}
 
-			// Tick mark
-			topY := timelineY - t.tickHeight
-			if i == 0 || i == t.numTicks {
-				topY = t.marginTop
-			}
-			group.Elements = append(group.Elements,
-				line{X1: x, Y1: float64(topY), X2: x, Y2: float64(timelineY + t.tickHeight)},
-			)
+		startX, endX := t.xAt(l, segStart), t.xAt(l, segEnd)
+		if t.snapToTicks {
+			startX, endX = t.snapX(l, startX), t.snapX(l, endX)
+		}
+		class := "tl-segment"
+		if seg.Class != "" {
+			class += " " + seg.Class
+		}
+		group.Elements = append(group.Elements,
+			rect{Class: class, X: startX, Y: float64(y), Width: endX - startX, Height: float64(height), FillOpacity: event.Opacity},
+		)
 
-			// Tick label
-			label := formatDuration(currentDuration, 2)
+		if seg.Text == "" {
+			continue
+		}
+		if textSize := t.labelSize(seg.Text, endX-startX, height); textSize >= t.minLabelFontSize {
 			group.Elements = append(group.Elements,
-				text{X: x, Y: float64(timelineY + t.tickHeight + t.tickLabelMargin), FontSize: "12", FontFamily: "monospace", TextAnchor: "middle", Content: label},
+				text{Class: "tl-segment-text", X: t.coord(startX + (endX-startX)/2), Y: float64(y) + float64(height)/2, FontSize: t.fontSize(textSize), FontFamily: t.fontFamily(), DominantBaseline: "middle", TextAnchor: "middle", Content: seg.Text},
 			)
 		}
 	}
-	root.Elements = append(root.Elements, group)
+}
 
-	var sb strings.Builder
-	encoder := xml.NewEncoder(&sb)
-	encoder.Indent("", "  ")
-	if err := encoder.Encode(root); err != nil {
-		return "", err
+// drawAnnotations draws the annotations of an event starting at the given offset of the visible window
+func (t *Timeline) drawAnnotations(l *layout, group *g, event Event, start time.Duration, y, height int) {
+	fontSize := min(height/3, annotationFontSize)
+	for _, a := range event.Annotations {
+		d := start + a.Offset
+		if a.Offset < 0 || a.Offset > event.Duration || d < 0 || d > l.maxDuration {
+			continue
+		}
+		x := t.xAt(l, d)
+		annotation := g{Class: "tl-annotation", Elements: []any{
+			line{X1: x, Y1: float64(y), X2: x, Y2: float64(y + height)},
+		}}
+		if a.Label != "" && fontSize >= t.minLabelFontSize {
+			annotation.Elements = append(annotation.Elements,
+				text{X: t.coord(x + labelPadding), Y: float64(y + labelPadding), FontSize: t.fontSize(fontSize), FontFamily: t.fontFamily(), DominantBaseline: "hanging", TextAnchor: "start", Content: a.Label},
+			)
+		}
+		group.Elements = append(group.Elements, annotation)
 	}
-	return sb.String(), nil
 }
 
-// setup initializes timeline variables and ensures consistency across events
-// - if any event sets its Time, all events must set it and the earliest time is returned
-// - at least one event must have a duration greater than 0
-func (t *Timeline) setup() error {
-	var hasTime, hasNoTime bool
-	var duration time.Duration
+// xAt returns the x coordinate of an offset from the start of the visible window
+func (t *Timeline) xAt(l *layout, d time.Duration) float64 {
+	return t.coord(t.marginLeft + l.contentWidth*float64(d)/float64(l.maxDuration))
+}
 
-	for _, r := range t.rows {
-		for _, e := range r.events {
-			if e.Duration < 0 {
-				return fmt.Errorf("duration of events cannot be negative")
-			}
-			duration += e.Duration
-			if e.Time.IsZero() {
-				hasNoTime = true
-			} else {
-				hasTime = true
-			}
-		}
+// coord rounds a coordinate to the nearest integer when SetIntegerCoords is enabled
+func (t *Timeline) coord(v float64) float64 {
+	if t.integerCoords {
+		return math.Round(v)
 	}
+	return v
+}
 
-	if hasTime && hasNoTime {
-		return fmt.Errorf(`when "Time" is set on any Event, it must be set on all of them`)
+// scaleLength multiplies a CSS length such as "300" or "300px" by the factor
+//
+// Percentages and values that are not numeric are returned unchanged.
+func scaleLength(s string, factor float64) string {
+	if factor == 1 || strings.HasSuffix(s, "%") {
+		return s
 	}
-
-	if duration == 0 {
-		return fmt.Errorf("none of the events has a positive duration")
+	num := strings.TrimRightFunc(s, unicode.IsLetter)
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return s
 	}
+	return strconv.FormatFloat(v*factor, 'f', -1, 64) + s[len(num):]
+}
 
-	// Initialize variables
-	t.tickLabelMargin = 15
-	t.maxDuration = t.MaxDuration()
-	t.contentHeight = t.TotalRowHeight()
-	t.earliest = t.StartTime()
-	t.totalHeight = t.contentHeight + t.marginTop + t.marginBottom + t.tickHeight + t.tickLabelMargin
-	if t.height == "" {
-		t.height = strconv.Itoa(t.totalHeight)
+// labelSize returns the font size for a label to fit the given width within a row
+//
+// Labels with several lines share the row height, each line must fit the width.
+func (t *Timeline) labelSize(s string, width float64, rowHeight int) int {
+	lines := strings.Count(s, "\n") + 1
+	if t.glyphWidth != nil {
+		// Measured widths may not scale linearly, shrink the font until the label fits
+		size := rowHeight / (lines + 1)
+		if t.maxLabelFontSize > 0 {
+			size = min(size, t.maxLabelFontSize)
+		}
+		for size > 0 && t.textWidth(s, float64(size)) > width {
+			size--
+		}
+		return size
 	}
 
-	t.contentWidth = min(t.precision, float64(t.maxDuration))
-	t.totalWidth = t.contentWidth + t.marginLeft + t.marginRight
-
-	return nil
+	size := int(min(
+		float64(rowHeight/(lines+1)),
+		width/t.textWidth(s, 1),
+	))
+	if t.maxLabelFontSize > 0 {
+		size = min(size, t.maxLabelFontSize)
+	}
+	return size
 }
 
-// drawEvent draws an event in the timeline
-func (t *Timeline) drawEvent(root *svg, event Event, currentY, rowHeight int, currentDuration time.Duration) time.Duration {
-	if !t.earliest.IsZero() {
-		currentDuration = event.Time.Sub(t.earliest)
+// textWidth returns the width of a text with the given font size, the width of its widest line for multi-line texts
+//
+// The width is measured with the glyph width function when set and approximated
+// for a monospace font otherwise.
+func (t *Timeline) textWidth(s string, fontSize float64) float64 {
+	var widest float64
+	for line := range strings.SplitSeq(s, "\n") {
+		var w float64
+		if t.glyphWidth == nil {
+			w = float64(utf8.RuneCountInString(line)) * fontSize * textWidthFactor
+		} else {
+			for _, r := range line {
+				w += t.glyphWidth(r, fontSize)
+			}
+		}
+		widest = max(widest, w)
 	}
+	return widest
+}
 
-	startX := t.marginLeft + t.contentWidth*float64(currentDuration)/float64(t.maxDuration)
-	eventWidth := t.contentWidth * float64(event.Duration) / float64(t.maxDuration)
-
-	var height int
-	var strokeDashArray string
-	var textYOffset float64
-
-	if event.Type == EventTypeEra {
-		height = t.totalHeight - currentY - t.marginBottom - (t.tickHeight * 3)
-		strokeDashArray = fmt.Sprintf(`0,%f,%d,0`, eventWidth, height)
-		textYOffset = float64(rowHeight) / 3
-	} else {
-		height = rowHeight
-		textYOffset = float64(rowHeight) / 2
+// textLines returns the text element of a label, breaking it into tspan lines at newlines
+//
+// The lines are placed like a single line label with the dominant baseline of the
+// element, vertically centered around its y coordinate unless its baseline anchors
+// them at the top or the bottom.
+func textLines(el text) text {
+	if !strings.Contains(el.Content, "\n") {
+		return el
+	}
+	const lineHeight = 1.2 // in em
+	lines := strings.Split(el.Content, "\n")
+	el.Content = ""
+	for i, line := range lines {
+		dy := lineHeight
+		if i == 0 {
+			// Shift the first line up by the whole block for the bottom anchor and half of it in the middle
+			dy = lineHeight * float64(len(lines)-1) * float64(-labelBaselines[el.DominantBaseline]-1) / 2
+		}
+		el.Spans = append(el.Spans, tspan{X: el.X, Dy: strconv.FormatFloat(dy, 'f', -1, 64) + "em", Content: line})
 	}
+	return el
+}
 
-	class := "tl-event"
-	if event.Type == EventTypeEra {
-		class = "tl-era"
+// snapX returns the x coordinate of the tick nearest to x
+func (t *Timeline) snapX(l *layout, x float64) float64 {
+	if len(l.tickXs) == 0 {
+		return x
 	}
-	if event.Class != "" {
-		class += " " + event.Class
+	i, _ := slices.BinarySearch(l.tickXs, x)
+	switch {
+	case i == 0:
+		return l.tickXs[0]
+	case i == len(l.tickXs):
+		return l.tickXs[i-1]
+	case x-l.tickXs[i-1] < l.tickXs[i]-x:
+		return l.tickXs[i-1]
 	}
+	return l.tickXs[i]
+}
 
-	group := g{ID: event.ID, Class: class}
-
-	// Title
-	if event.Title != "" {
-		group.Elements = append(group.Elements,
-			title{Content: event.Title},
-		)
+// labelPosition returns the x coordinate and text-anchor for a label centered at x
+//
+// Labels that would overflow the content area are pinned to the nearest edge.
+func (t *Timeline) labelPosition(l *layout, x, textWidth float64) (float64, string) {
+	left := t.marginLeft
+	right := t.marginLeft + l.contentWidth
+	switch {
+	case x+textWidth/2 > right:
+		return right, "end"
+	case x-textWidth/2 < left:
+		return left, "start"
 	}
+	return x, "middle"
+}
 
-	// Rectangle
-	group.Elements = append(group.Elements,
-		rect{X: startX, Y: float64(currentY), Width: eventWidth, Height: float64(height), StrokeDasharray: strokeDashArray},
-	)
-
-	// Text
-	const textWidthFactor = 0.7
-	if event.Text != "" {
-		textSize := int(min(
-			float64(rowHeight/2),
-			eventWidth/(float64(len(event.Text))*textWidthFactor),
-		))
-		if event.Type == EventTypeEra {
-			textSize -= 1
-		}
-		if textSize >= 3 {
-			textX := startX + eventWidth/2
-			textY := float64(currentY) + textYOffset
+// clone returns a deep copy of the row
+func (r *Row) clone() *Row {
+	c := *r
+	c.events = append(make([]Event, 0, len(r.events)), r.events...)
+	for i := range c.events {
+		c.events[i].Segments = slices.Clone(c.events[i].Segments)
+		c.events[i].Annotations = slices.Clone(c.events[i].Annotations)
+	}
+	return &c
+}
 
-			group.Elements = append(group.Elements,
-				text{X: textX, Y: textY, FontSize: strconv.Itoa(textSize), FontFamily: "monospace", DominantBaseline: "middle", TextAnchor: "middle", Content: event.Text},
-			)
-		}
+// layoutHeight returns the height of the row, computing it for auto-sized rows
+func (r *Row) layoutHeight() int {
+	if r.height > 0 {
+		return r.height
+	}
+	// Labels of n lines are sized up to 1/(n+1) of the row height
+	lines := 1
+	for _, e := range r.events {
+		lines = max(lines, strings.Count(e.Text, "\n")+1)
 	}
+	return autoRowFontSize * (lines + 1)
+}
 
-	root.Elements = append(root.Elements, group)
+// SetStartOffset shifts the events of the row to the right by the given duration (duration mode only)
+//
+// Events positioned with Offset are shifted too, use Time to place events in time mode.
+func (r *Row) SetStartOffset(d time.Duration) {
+	r.startOffset = d
+}
 
-	if t.earliest.IsZero() {
-		currentDuration += event.Duration
-	}
+// SetBelowAxis draws the row beneath the axis and its labels instead of above it (default: false)
+//
+// Rows below the axis are stacked downwards in the order they were added, eras
+// in them cover their own row unless they span the following rows.
+func (r *Row) SetBelowAxis(below bool) {
+	r.below = below
+}
 
-	return currentDuration
+// GetEvents returns the row events
+func (r *Row) GetEvents() []Event {
+	return r.events
+}
+
+// EventCount returns the number of events of the row
+func (r *Row) EventCount() int {
+	return len(r.events)
 }
 
 // AddEvent adds an event to a row
@@ -420,15 +2785,43 @@ func (r *Row) AddEvent(e Event) {
 	r.events = append(r.events, e)
 }
 
+// AddTask adds a task to a row spanning from start to end
+func (r *Row) AddTask(start, end time.Time, text, class string) error {
+	return r.addSpan(EventTypeTask, start, end, text, class)
+}
+
+// AddEra adds an era to a row spanning from start to end
+func (r *Row) AddEra(start, end time.Time, text, class string) error {
+	return r.addSpan(EventTypeEra, start, end, text, class)
+}
+
+// addSpan adds an event of the given type to a row spanning from start to end
+func (r *Row) addSpan(eventType EventType, start, end time.Time, text, class string) error {
+	if !end.After(start) {
+		return fmt.Errorf("the end of the event must be after its start")
+	}
+	r.AddEvent(Event{Type: eventType, Text: text, Class: class, Time: start, Duration: end.Sub(start)})
+	return nil
+}
+
 // TotalDuration returns the total duration for a row
 func (r *Row) TotalDuration(earliest time.Time) time.Duration {
-	var total time.Duration
+	var total, current time.Duration
 	var maxByTime time.Duration
+	if earliest.IsZero() {
+		current = r.startOffset
+	}
 
 	for _, event := range r.events {
-		total += event.Duration
+		if event.explicitOffset() {
+			current = r.startOffset + event.Offset
+		} else if earliest.IsZero() {
+			current += event.Gap
+		}
+		current += event.Duration
+		total = max(total, current)
 		if !earliest.IsZero() && !event.Time.IsZero() {
-			byTime := event.Time.Sub(earliest) + event.Duration
+			byTime := event.Time.Sub(earliest) + max(event.Duration, 0)
 			if byTime > maxByTime {
 				maxByTime = byTime
 			}
@@ -437,6 +2830,28 @@ func (r *Row) TotalDuration(earliest time.Time) time.Duration {
 	return max(total, maxByTime)
 }
 
+// minStart returns the smallest start offset of the row events which is only
+// negative when events with a negative duration extend before the timeline start
+func (r *Row) minStart(earliest time.Time) time.Duration {
+	var m, current time.Duration
+	if earliest.IsZero() {
+		current = r.startOffset
+	}
+	for _, event := range r.events {
+		switch {
+		case !earliest.IsZero():
+			current = event.Time.Sub(earliest)
+		case event.explicitOffset():
+			current = r.startOffset + event.Offset
+		default:
+			current += event.Gap
+		}
+		current += event.Duration
+		m = min(m, current)
+	}
+	return m
+}
+
 // StartTime returns the earliest time that is currently set on the row
 // given the existing events
 func (r *Row) StartTime() time.Time {
@@ -460,26 +2875,10 @@ func (r *Row) EndTime() time.Time {
 		if e.Time.IsZero() {
 			continue
 		}
-		eventEnd := e.Time.Add(e.Duration)
+		eventEnd := e.Time.Add(max(e.Duration, 0))
 		if end.IsZero() || eventEnd.After(end) {
 			end = eventEnd
 		}
 	}
 	return end
 }
-
-// formatDuration rounds a time.Duration to the given digits and returns its String()
-func formatDuration(d time.Duration, digits int) string {
-	div := time.Duration(math.Pow(10, float64(digits)))
-	switch {
-	case d > time.Second:
-		d = d.Round(time.Second / div)
-	case d > time.Millisecond:
-		d = d.Round(time.Millisecond / div)
-	case d > time.Microsecond:
-		d = d.Round(time.Microsecond / div)
-	case d > time.Nanosecond:
-		d = d.Round(time.Nanosecond / div)
-	}
-	return d.String()
-}
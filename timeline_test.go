@@ -4,12 +4,23 @@ package svgtimeline_test
 
 import (
 	_ "embed"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	svgtimeline "github.com/aorith/svg-timeline"
+	"golang.org/x/text/language"
 )
 
 //go:embed tests/test1.svg
@@ -161,13 +172,2644 @@ func TestNewTimeline(t *testing.T) {
 			if err != nil {
 				fmt.Printf("%v\n", err)
 			}
-			if svg != tt.want {
+			if svgtimeline.NormalizeSVG(svg) != svgtimeline.NormalizeSVG(tt.want) {
 				gotFn := fmt.Sprintf("%d_got_test.svg", i)
 				wantFn := fmt.Sprintf("%d_want_test.svg", i)
 				t.Errorf(`[%s] failed, resulting svg files saved as "%s" and "%s"`, tt.name, gotFn, wantFn)
 				_ = os.WriteFile(gotFn, []byte(svg), 0o644)
 				_ = os.WriteFile(wantFn, []byte(tt.want), 0o644)
+			} else if svg != tt.want {
+				t.Errorf("[%s] output is not byte-identical to the golden file", tt.name)
 			}
 		})
 	}
 }
+
+func TestClone(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "original", Duration: time.Second})
+	want, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := tl.Clone()
+	c.SetWidth("500")
+	c.SetStyle("")
+	c.GetLastRow().AddEvent(svgtimeline.Event{Text: "clone", Duration: time.Second})
+	c.AddRow(20, 0).AddEvent(svgtimeline.Event{Text: "new row", Duration: 3 * time.Second})
+	if _, err := c.Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(tl.GetRows()); n != 1 {
+		t.Errorf("original timeline has %d rows, want 1", n)
+	}
+	if d := tl.MaxDuration(); d != time.Second {
+		t.Errorf("original timeline max duration is %v, want 1s", d)
+	}
+	got, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("original timeline output changed after mutating its clone")
+	}
+}
+
+func TestConcurrentGenerate(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetSmallEventLabelMode(svgtimeline.SmallLabelLeader)
+	row := tl.AddRow(30, 5)
+	row.AddEvent(svgtimeline.Event{ID: "a", Text: "first", Duration: time.Second})
+	row.AddEvent(svgtimeline.Event{ID: "b", Text: "a label too long to fit", Duration: time.Millisecond})
+	want, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			if got, err := tl.Generate(); err != nil || got != want {
+				t.Errorf("concurrent Generate differs from the sequential one (error: %v)", err)
+			}
+			if _, err := tl.Layout(); err != nil {
+				t.Error(err)
+			}
+			tl.Dimensions()
+			tl.EventBox("b")
+		})
+	}
+	wg.Wait()
+}
+
+func TestGenerateIdempotent(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "first", Duration: time.Second})
+
+	first, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("consecutive calls to Generate returned different output")
+	}
+
+	// The height is derived on each call instead of being stored on the first one
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "second", Duration: time.Second})
+	third, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(third, `height="120"`) {
+		t.Errorf("height was not recomputed after adding a row:\n%s", third)
+	}
+}
+
+func TestSetBackground(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second})
+
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, `<rect class="tl-bg" x="0" y="0" width="1040" height="85" fill="none">`) {
+		t.Errorf("default background is not transparent:\n%s", svg)
+	}
+
+	tl.SetBackground(`#fff" onload="alert(1)`)
+	svg, err = tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, `<rect class="tl-bg" x="0" y="0" width="1040" height="85" fill="#fff&#34; onload=&#34;alert(1)">`) {
+		t.Errorf("background fill is not escaped or lost the tl-bg class:\n%s", svg)
+	}
+}
+
+func TestInvalidContentWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(tl *svgtimeline.Timeline)
+	}{
+		{name: "Zero precision", setup: func(tl *svgtimeline.Timeline) { tl.SetPrecision(0) }},
+		{name: "Negative precision", setup: func(tl *svgtimeline.Timeline) { tl.SetPrecision(-10) }},
+		{name: "Negative margins", setup: func(tl *svgtimeline.Timeline) { tl.SetMargins(0, -600, 0, -600) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second})
+			tt.setup(tl)
+			if svg, err := tl.Generate(); err == nil {
+				t.Errorf("expected an error, got:\n%s", svg)
+			}
+		})
+	}
+}
+
+func TestDurationFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   svgtimeline.DurationFormat
+		duration time.Duration
+		want     []string
+	}{
+		{"go", svgtimeline.DurationFormatGo, time.Hour + 2*time.Minute + 3*time.Second, []string{"0s", "31m1.5s", "1h2m3s"}},
+		{"clock", svgtimeline.DurationFormatClock, time.Hour + 2*time.Minute + 3*time.Second, []string{"00:00:00", "00:31:01.5", "01:02:03"}},
+		{"clock below a second", svgtimeline.DurationFormatClock, 1500 * time.Millisecond, []string{"00:00:00", "00:00:00.75", "00:00:01.5"}},
+		{"decimal unit", svgtimeline.DurationFormatDecimalUnit, time.Hour + 2*time.Minute + 24*time.Second, []string{"0s", "31.2m", "1.04h"}},
+		{"decimal unit below a second", svgtimeline.DurationFormatDecimalUnit, 1500 * time.Millisecond, []string{"0s", "750ms", "1.5s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			tl.SetNumTicks(2)
+			tl.SetDurationFormat(tt.format)
+			tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: tt.duration})
+			layout, err := tl.Layout()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, tick := range layout.Ticks {
+				got = append(got, tick.Label)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("tick labels = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTickLabelsDistinct(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(tl *svgtimeline.Timeline)
+	}{
+		{name: "Sub-microsecond duration", setup: func(tl *svgtimeline.Timeline) {}},
+		{name: "Sub-microsecond window", setup: func(tl *svgtimeline.Timeline) {
+			tl.SetDurationWindow(time.Second, time.Second+800*time.Nanosecond)
+		}},
+		{name: "Sub-microsecond window in clock format", setup: func(tl *svgtimeline.Timeline) {
+			tl.SetDurationWindow(time.Second, time.Second+800*time.Nanosecond)
+			tl.SetDurationFormat(svgtimeline.DurationFormatClock)
+		}},
+	}
+
+	labelRe := regexp.MustCompile(`<text [^>]*text-anchor="middle">([^<]*)</text>`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 2 * time.Second})
+			tl.SetMaxDurationOverride(800 * time.Nanosecond)
+			tt.setup(tl)
+			svg, err := tl.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ticks := svg[strings.Index(svg, `<g class="tl-ticks">`):]
+			seen := map[string]bool{}
+			for _, m := range labelRe.FindAllStringSubmatch(ticks, -1) {
+				if seen[m[1]] {
+					t.Errorf("duplicated tick label %q:\n%s", m[1], ticks)
+				}
+				seen[m[1]] = true
+			}
+			if len(seen) != 9 {
+				t.Errorf("got %d distinct tick labels, want 9", len(seen))
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetWidth("500")
+	tl.SetNumTicks(4)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "old", Duration: 10 * time.Second})
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "old", Duration: 20 * time.Second})
+	if _, err := tl.Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tl.Reset()
+	if n := len(tl.GetRows()); n != 0 {
+		t.Fatalf("timeline has %d rows after Reset, want 0", n)
+	}
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error generating a timeline without events")
+	}
+	tl.AddRow(20, 0).AddEvent(svgtimeline.Event{Text: "new", Duration: time.Second})
+	got, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := svgtimeline.NewTimeline()
+	fresh.SetWidth("500")
+	fresh.SetNumTicks(4)
+	fresh.AddRow(20, 0).AddEvent(svgtimeline.Event{Text: "new", Duration: time.Second})
+	want, err := fresh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("reset timeline differs from a fresh one with the same config:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEventDecorator(t *testing.T) {
+	type desc struct {
+		XMLName xml.Name `xml:"desc"`
+		Content string   `xml:",chardata"`
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "ev-1", Text: "decorated", Duration: time.Second})
+	tl.SetEventDecorator(func(e svgtimeline.Event, group *svgtimeline.EventGroup) {
+		group.Class += " decorated"
+		group.Elements = append(group.Elements, desc{Content: "about " + e.ID})
+	})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<g id="ev-1" class="tl-event decorated">`, `<desc>about ev-1</desc>`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+}
+
+func TestLinks(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "plain", Duration: time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(svg, "xmlns:xlink") {
+		t.Errorf("xlink namespace declared without links:\n%s", svg)
+	}
+
+	tl.GetLastRow().AddEvent(svgtimeline.Event{Text: "first", Duration: time.Second, URL: "https://example.com/?a=1&b=2"})
+	tl.GetLastRow().AddEvent(svgtimeline.Event{Text: "second", Duration: time.Second, URL: "#first"})
+	svg, err = tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(svg, `xmlns:xlink="http://www.w3.org/1999/xlink"`); n != 1 {
+		t.Errorf("xlink namespace declared %d times, want 1:\n%s", n, svg)
+	}
+	if !strings.Contains(svg, `<a xlink:href="https://example.com/?a=1&amp;b=2">`) {
+		t.Errorf("link not rendered:\n%s", svg)
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("output is not valid XML: %v", err)
+		}
+	}
+}
+
+func TestGetRowByIndex(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "a", Duration: time.Second})
+	tl.AddRow(30, 5)
+	if n := tl.RowCount(); n != 2 {
+		t.Fatalf("RowCount() = %d, want 2", n)
+	}
+	for _, i := range []int{-1, 2, 10} {
+		if row := tl.GetRowByIndex(i); row != nil {
+			t.Errorf("GetRowByIndex(%d) = %v, want nil", i, row)
+		}
+	}
+	for i, want := range []int{1, 0} {
+		row := tl.GetRowByIndex(i)
+		if row == nil {
+			t.Fatalf("GetRowByIndex(%d) = nil", i)
+		}
+		if n := row.EventCount(); n != want {
+			t.Errorf("row %d EventCount() = %d, want %d", i, n, want)
+		}
+	}
+}
+
+func TestMaxLabelFontSize(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(100, 5).AddEvent(svgtimeline.Event{Text: "tall", Duration: time.Second})
+	tl.SetMaxLabelFontSize(14)
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, `font-size="14"`) || strings.Contains(svg, `font-size="50"`) {
+		t.Errorf("label font size is not capped at 14:\n%s", svg)
+	}
+}
+
+func TestSetScale(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetWidth("500px")
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "a", Duration: time.Second})
+	normal, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, h := tl.Dimensions()
+
+	tl.SetScale(2)
+	scaled, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := regexp.MustCompile(`<svg [^>]*>`)
+	want := fmt.Sprintf(`width="1000px" height="%v" viewBox="0 0 %f %f"`, 2*h, w, h)
+	if got := root.FindString(scaled); !strings.Contains(got, want) {
+		t.Errorf("scaled root element %s does not contain %s", got, want)
+	}
+	if strings.TrimPrefix(scaled, root.FindString(scaled)) != strings.TrimPrefix(normal, root.FindString(normal)) {
+		t.Errorf("scaling changed the content of the SVG")
+	}
+
+	tl.SetScale(0)
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error for a zero scale")
+	}
+}
+
+func TestStrictIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []string
+		wantErr string
+	}{
+		{"valid", []string{"ev-1", "ev_2", "", ""}, ""},
+		{"duplicate", []string{"ev-1", "ev-1"}, `duplicate event ID "ev-1"`},
+		{"space", []string{"ev 1"}, `invalid event ID "ev 1"`},
+		{"digit", []string{"1ev"}, `invalid event ID "1ev"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			row := tl.AddRow(30, 5)
+			for _, id := range tt.ids {
+				row.AddEvent(svgtimeline.Event{ID: id, Duration: time.Second})
+			}
+			if _, err := tl.Generate(); err != nil {
+				t.Fatalf("lax mode returned an error: %v", err)
+			}
+
+			tl.SetStrictIDs(true)
+			_, err := tl.Generate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInteractive(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "fetch", Title: "GET /", Duration: 2 * time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(svg, "<script") {
+		t.Errorf("script rendered without SetInteractive:\n%s", svg)
+	}
+
+	tl.SetInteractive(true)
+	svg, err = tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`data-tooltip="fetch&#xA;GET /&#xA;duration: 2s"`, `<g class="tl-tooltip">`, "<script><![CDATA["} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+	if strings.Contains(svg, "<title>") {
+		t.Errorf("native title rendered in interactive mode:\n%s", svg)
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("output is not valid XML: %v", err)
+		}
+	}
+}
+
+func TestRowOrder(t *testing.T) {
+	rowY := func(order svgtimeline.RowOrder) map[string]string {
+		tl := svgtimeline.NewTimeline()
+		tl.SetRowOrder(order)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "first", Duration: time.Second})
+		tl.AddRow(20, 5).AddEvent(svgtimeline.Event{ID: "second", Duration: time.Second})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ys := make(map[string]string)
+		re := regexp.MustCompile(`<g id="(\w+)" [^>]*>\s*<rect [^>]*y="([^"]+)"`)
+		for _, m := range re.FindAllStringSubmatch(svg, -1) {
+			ys[m[1]] = m[2]
+		}
+		return ys
+	}
+
+	topDown := rowY(svgtimeline.RowOrderTopDown)
+	bottomUp := rowY(svgtimeline.RowOrderBottomUp)
+	want := map[string]string{"first": "15", "second": "50"}
+	if fmt.Sprint(topDown) != fmt.Sprint(want) {
+		t.Errorf("top-down row positions = %v, want %v", topDown, want)
+	}
+	// The content spans from y=15 to y=75
+	want = map[string]string{"first": "45", "second": "20"}
+	if fmt.Sprint(bottomUp) != fmt.Sprint(want) {
+		t.Errorf("bottom-up row positions = %v, want %v", bottomUp, want)
+	}
+}
+
+func TestSetMaxWidth(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetPrecision(100000)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Minute})
+	if w, _ := tl.Dimensions(); w != 100040 {
+		t.Fatalf("uncapped width = %v, want 100040", w)
+	}
+	tl.SetMaxWidth(800)
+	if w, _ := tl.Dimensions(); w != 800 {
+		t.Errorf("capped width = %v, want 800", w)
+	}
+	tl.SetMaxWidth(20)
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error when the max width leaves no space for the content")
+	}
+}
+
+func TestSetStyleHref(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second})
+	tl.SetStyleHref("/static/timeline.css?v=1&x=2")
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml-stylesheet type="text/css" href="/static/timeline.css?v=1&amp;x=2"?>` + "\n<svg "
+	if !strings.HasPrefix(svg, want) {
+		t.Errorf("output does not start with %q:\n%s", want, svg)
+	}
+	if strings.Contains(svg, "<style>") {
+		t.Errorf("style inlined along with the stylesheet reference:\n%s", svg)
+	}
+
+	page, err := tl.GenerateHTML("timeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page, `<link rel="stylesheet" href="/static/timeline.css?v=1&amp;x=2">`) {
+		t.Errorf("page does not link the stylesheet:\n%s", page)
+	}
+}
+
+func TestGenerateHTML(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetInteractive(true)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "api", Text: "api", Duration: time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := tl.GenerateHTML("Requests <2025>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head := "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Requests &lt;2025&gt;</title>\n<style>\nbody {"
+	if !strings.HasPrefix(page, head) {
+		t.Errorf("page does not start with %q:\n%s", head, page)
+	}
+	if body := "</head>\n<body>\n" + svg + "\n</body>\n</html>\n"; !strings.HasSuffix(page, body) {
+		t.Errorf("page does not end with the inline SVG in its body:\n%s", page)
+	}
+	for _, want := range []string{`<g class="tl-tooltip">`, "<script><![CDATA[\n(function () {"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain the tooltip %q:\n%s", want, page)
+		}
+	}
+
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: -time.Second})
+	if _, err := tl.GenerateHTML("invalid"); err == nil {
+		t.Errorf("expected the error of Generate")
+	}
+}
+
+func TestZIndex(t *testing.T) {
+	order := func(z int) []string {
+		tl := svgtimeline.NewTimeline()
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "era", Type: svgtimeline.EventTypeEra, Duration: 2 * time.Second})
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "task", Duration: time.Second, ZIndex: z})
+		tl.GetLastRow().AddEvent(svgtimeline.Event{ID: "next", Duration: time.Second})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, m := range regexp.MustCompile(`<g id="(\w+)"`).FindAllStringSubmatch(svg, -1) {
+			ids = append(ids, m[1])
+		}
+		return ids
+	}
+
+	if got, want := order(0), []string{"era", "task", "next"}; !slices.Equal(got, want) {
+		t.Errorf("default draw order = %v, want %v", got, want)
+	}
+	if got, want := order(1), []string{"era", "next", "task"}; !slices.Equal(got, want) {
+		t.Errorf("draw order with ZIndex = %v, want %v", got, want)
+	}
+	if got, want := order(-1), []string{"task", "era", "next"}; !slices.Equal(got, want) {
+		t.Errorf("draw order with negative ZIndex = %v, want %v", got, want)
+	}
+}
+
+func TestEventBox(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "first", Duration: 10 * time.Second})
+	tl.GetLastRow().AddEvent(svgtimeline.Event{ID: "second", Duration: 10 * time.Second})
+
+	x, y, w, h, ok := tl.EventBox("second")
+	if !ok {
+		t.Fatalf("EventBox did not find the event")
+	}
+	if x != 510 || y != 15 || w != 500 || h != 30 {
+		t.Errorf("EventBox = (%v, %v, %v, %v), want (510, 15, 500, 30)", x, y, w, h)
+	}
+	if _, _, _, _, ok := tl.EventBox("missing"); ok {
+		t.Errorf("EventBox found an unknown ID")
+	}
+}
+
+func TestSetTickUnit(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetNumTicks(3)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 3 * time.Hour})
+	tl.SetTickUnit(time.Second)
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{">0s</text>", ">3600s</text>", ">10800s</text>"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+
+	tl.SetNumberLocale(language.English)
+	svg, err = tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, ">10,800s</text>") {
+		t.Errorf("tick labels are not grouped with the locale:\n%s", svg)
+	}
+
+	for _, unit := range []time.Duration{0, -time.Second, 2 * time.Second} {
+		tl.SetTickUnit(unit)
+		if _, err := tl.Generate(); err == nil {
+			t.Errorf("expected an error for the tick unit %v", unit)
+		}
+	}
+}
+
+func TestSetTickLabelDigits(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetNumTicks(1)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 1437 * time.Millisecond})
+	for digits, want := range map[int]string{3: ">1.437s</text>", 2: ">1.44s</text>", 0: ">1s</text>"} {
+		tl.SetTickLabelDigits(digits)
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(svg, want) {
+			t.Errorf("%d digits: output does not contain %q:\n%s", digits, want, svg)
+		}
+	}
+
+	tl.SetTickLabelDigits(-1)
+	if _, err := tl.Generate(); err == nil {
+		t.Error("expected an error for negative digits")
+	}
+}
+
+func TestEventGroups(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	row := tl.AddRow(30, 5)
+	row.AddEvent(svgtimeline.Event{ID: "a", Duration: time.Second, Group: "db"})
+	row.AddEvent(svgtimeline.Event{ID: "b", Duration: time.Second, Group: "db"})
+	row.AddEvent(svgtimeline.Event{ID: "c", Duration: time.Second})
+	row.AddEvent(svgtimeline.Event{ID: "d", Duration: time.Second, Group: "db"})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(svg, `<g class="tl-group" data-group="db">`); n != 2 {
+		t.Errorf("got %d group wrappers, want 2:\n%s", n, svg)
+	}
+	re := regexp.MustCompile(`(?s)<g class="tl-group" data-group="db">\s*<g id="a" .*?</g>\s*<g id="b" .*?</g>\s*</g>\s*<g id="c" `)
+	if !re.MatchString(svg) {
+		t.Errorf("consecutive events are not wrapped together:\n%s", svg)
+	}
+}
+
+func TestSetContentWidth(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetWidth("600px")
+	tl.SetContentWidth(2000)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "a", Duration: time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, _ := tl.Dimensions(); w != 2040 {
+		t.Errorf("viewBox width = %v, want 2040", w)
+	}
+	if !strings.Contains(svg, `width="600px"`) {
+		t.Errorf("display width not kept:\n%s", svg)
+	}
+	if _, _, w, _, _ := tl.EventBox("a"); w != 2000 {
+		t.Errorf("event width = %v, want 2000", w)
+	}
+}
+
+func TestNormalizeSVG(t *testing.T) {
+	a := `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="5"><!-- note --><g class="x"  id="y"><text x="1" y="2">a   label</text></g></svg>`
+	b := "<svg height=\"5\" width=\"10\" xmlns=\"http://www.w3.org/2000/svg\">\n  <g id=\"y\" class=\"x\">\n    <text y=\"2\" x=\"1\">\n      a label\n    </text>\n  </g>\n</svg>\n"
+	if got, want := svgtimeline.NormalizeSVG(a), svgtimeline.NormalizeSVG(b); got != want {
+		t.Errorf("equivalent documents normalize differently:\n%s\n%s", got, want)
+	}
+	if svgtimeline.NormalizeSVG(a) == svgtimeline.NormalizeSVG(strings.Replace(a, `x="1"`, `x="2"`, 1)) {
+		t.Errorf("documents with different attribute values normalize the same")
+	}
+}
+
+func TestSpanRows(t *testing.T) {
+	eraHeight := func(span int) float64 {
+		tl := svgtimeline.NewTimeline()
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "era", Type: svgtimeline.EventTypeEra, Duration: time.Second, SpanRows: span})
+		for range 3 {
+			tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second})
+		}
+		_, _, _, h, ok := tl.EventBox("era")
+		if !ok {
+			t.Fatalf("era not drawn")
+		}
+		return h
+	}
+
+	all := eraHeight(0)
+	tests := []struct {
+		span int
+		want float64
+	}{
+		{1, 30},
+		{2, 65},
+		{3, 100},
+		{4, all},
+		{10, all},
+	}
+	for _, tt := range tests {
+		if got := eraHeight(tt.span); got != tt.want {
+			t.Errorf("era spanning %d rows has a height of %v, want %v", tt.span, got, tt.want)
+		}
+	}
+}
+
+func TestEndLabelsOnly(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetNumTicks(4)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 4 * time.Second})
+	tl.SetEndLabelsOnly(true)
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticks := svg[strings.Index(svg, `<g class="tl-ticks">`):]
+	if n := strings.Count(ticks, "<line "); n != 5 {
+		t.Errorf("got %d tick marks, want 5", n)
+	}
+	labels := regexp.MustCompile(`<text x="([^"]+)"[^>]* text-anchor="(\w+)">([^<]*)</text>`).FindAllStringSubmatch(ticks, -1)
+	if len(labels) != 2 {
+		t.Fatalf("got %d tick labels, want 2:\n%s", len(labels), ticks)
+	}
+	if got := labels[0][1:]; got[0] != "10" || got[1] != "start" || got[2] != "0s" {
+		t.Errorf("first label = %v, want [10 start 0s]", got)
+	}
+	if got := labels[1][1:]; got[0] != "1010" || got[1] != "end" || got[2] != "4s" {
+		t.Errorf("last label = %v, want [1010 end 4s]", got)
+	}
+}
+
+func TestStrokeWidths(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetNumTicks(2)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second})
+	tl.SetAxisStrokeWidth(3)
+	tl.SetTickStrokeWidth(1)
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`<line class="tl-axis" [^>]*stroke-width="3"`).MatchString(svg) {
+		t.Errorf("axis stroke width not set:\n%s", svg)
+	}
+	ticks := svg[strings.Index(svg, `<g class="tl-ticks">`):]
+	if n := strings.Count(ticks, `stroke-width="1"`); n != 3 {
+		t.Errorf("got %d ticks with the stroke width, want 3:\n%s", n, ticks)
+	}
+}
+
+func TestPercentageHeight(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second})
+	_, h := tl.Dimensions()
+	tl.SetHeight("100%")
+	tl.SetPreserveAspectRatio("none")
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf(`height="100%%" viewBox="0 0 1040.000000 %f" preserveAspectRatio="none"`, h)
+	if !strings.Contains(svg, want) {
+		t.Errorf("output does not contain %q:\n%s", want, svg)
+	}
+}
+
+func TestBadge(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "wide", Duration: 10 * time.Second, Badge: "3×"})
+	tl.GetLastRow().AddEvent(svgtimeline.Event{ID: "narrow", Duration: 10 * time.Millisecond, Badge: "2×"})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(svg, `<g class="tl-badge">`); n != 1 {
+		t.Errorf("got %d badges, want 1 on the wide event only:\n%s", n, svg)
+	}
+	if !regexp.MustCompile(`(?s)<g id="wide" .*?<g class="tl-badge">\s*<circle [^>]*>.*?>3×</text>`).MatchString(svg) {
+		t.Errorf("badge not drawn in the wide event:\n%s", svg)
+	}
+}
+
+func TestFontSizeUnit(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "a", Duration: time.Second})
+	tl.SetFontSizeUnit("rem")
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`font-size="0.75rem"`, `font-size="0.9375rem"`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+	if regexp.MustCompile(`font-size="\d+"`).MatchString(svg) {
+		t.Errorf("output contains unitless font sizes:\n%s", svg)
+	}
+
+	tl.SetFontSizeUnit("pt")
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error for an unknown font size unit")
+	}
+}
+
+func TestZeroMaxDuration(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetAllowNegativeDurations(true)
+	// Ends exactly at the start of the timeline so the axis spans no time
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: -5 * time.Second, Offset: 5 * time.Second})
+	svg, err := tl.Generate()
+	if err == nil {
+		t.Fatalf("expected an error for a timeline spanning no time, got:\n%s", svg)
+	}
+	if !strings.Contains(err.Error(), "span no time") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNegativeDurations(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetAllowNegativeDurations(true)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "deploy", Duration: 10 * time.Second})
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "rollback", Duration: -5 * time.Second})
+	layout, err := tl.Layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("class", func(t *testing.T) {
+		if got := layout.Events[1].Class; got != "tl-event tl-negative" {
+			t.Errorf("class = %q, want %q", got, "tl-event tl-negative")
+		}
+		if got := layout.Events[0].Class; got != "tl-event" {
+			t.Errorf("class of the positive event = %q, want %q", got, "tl-event")
+		}
+	})
+
+	t.Run("extends to the left of its start", func(t *testing.T) {
+		deploy, rollback := layout.Events[0], layout.Events[1]
+		if rollback.X+rollback.Width != deploy.X {
+			t.Errorf("negative event ends at %v, want its start %v", rollback.X+rollback.Width, deploy.X)
+		}
+		if rollback.X != 10 || rollback.Width <= 0 {
+			t.Errorf("negative event drawn at x=%v width=%v, want it from the content start", rollback.X, rollback.Width)
+		}
+	})
+
+	t.Run("axis range", func(t *testing.T) {
+		first, last := layout.Ticks[0], layout.Ticks[len(layout.Ticks)-1]
+		if first.Duration != -5*time.Second || first.Label != "-5s" {
+			t.Errorf("first tick = %v %q, want -5s", first.Duration, first.Label)
+		}
+		if last.Duration != 10*time.Second {
+			t.Errorf("last tick = %v, want 10s", last.Duration)
+		}
+	})
+
+	tl.SetAllowNegativeDurations(false)
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error for a negative duration when they are not allowed")
+	}
+}
+
+func TestHiddenEvents(t *testing.T) {
+	axis := func(svg string) string {
+		return svg[strings.Index(svg, `<line class="tl-axis"`):]
+	}
+
+	reference := svgtimeline.NewTimeline()
+	reference.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "shown", Duration: 2 * time.Second})
+	reference.GetLastRow().AddEvent(svgtimeline.Event{ID: "pad", Duration: 8 * time.Second})
+	want, err := reference.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "shown", Duration: 2 * time.Second})
+	tl.GetLastRow().AddEvent(svgtimeline.Event{ID: "pad", Duration: 8 * time.Second, Hidden: true})
+	got, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if axis(got) != axis(want) {
+		t.Errorf("axis differs with a hidden event:\ngot:\n%s\nwant:\n%s", axis(got), axis(want))
+	}
+	if strings.Contains(got, `id="pad"`) {
+		t.Errorf("hidden event rendered:\n%s", got)
+	}
+}
+
+func TestTicks(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetNumTicks(4)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 4 * time.Second})
+	ticks := tl.Ticks()
+	if len(ticks) != 5 {
+		t.Fatalf("got %d ticks, want 5", len(ticks))
+	}
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tick := range ticks {
+		if tick.Index != i || tick.Duration != time.Duration(i)*time.Second || tick.X != float64(10+250*i) {
+			t.Errorf("tick %d = %+v", i, tick)
+		}
+		if want := fmt.Sprintf(`<text x="%d" y="[^"]+" font-size="12" font-family="monospace" text-anchor="middle">%s</text>`, 10+250*i, tick.Label); !regexp.MustCompile(want).MatchString(svg) {
+			t.Errorf("tick %d label %q not drawn at its position", i, tick.Label)
+		}
+	}
+
+	tl.SetEndLabelsOnly(true)
+	ticks = tl.Ticks()
+	if ticks[0].Label == "" || ticks[1].Label != "" || ticks[4].Label == "" {
+		t.Errorf("unexpected labels with SetEndLabelsOnly: %+v", ticks)
+	}
+}
+
+func TestGlyphWidthFunc(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "iiiiiiiiii", Duration: 2 * time.Second})
+	tl.GetLastRow().AddEvent(svgtimeline.Event{Duration: 98 * time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(svg, ">iiiiiiiiii</text>") {
+		t.Fatalf("label fits with the monospace approximation, the test needs a narrower event:\n%s", svg)
+	}
+
+	tl.SetGlyphWidthFunc(func(r rune, fontSize float64) float64 {
+		if r == 'i' {
+			return 0.2 * fontSize
+		}
+		return 0.6 * fontSize
+	})
+	svg, err = tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, `font-size="10" font-family="monospace" text-anchor="middle" dominant-baseline="middle">iiiiiiiiii</text>`) {
+		t.Errorf("label not fitted with the measured glyph widths:\n%s", svg)
+	}
+}
+
+func TestEraLabelStacking(t *testing.T) {
+	labelY := func(stack bool) []string {
+		tl := svgtimeline.NewTimeline()
+		tl.SetEraLabelPosition(svgtimeline.EraLabelBottom)
+		tl.SetEraLabelStacking(stack)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Type: svgtimeline.EventTypeEra, Text: "outer", Duration: 10 * time.Second})
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Type: svgtimeline.EventTypeEra, Text: "inner", Duration: 5 * time.Second})
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "task", Duration: 10 * time.Second})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ys []string
+		for _, m := range regexp.MustCompile(`<text [^>]* y="([^"]+)"[^>]*>(outer|inner)</text>`).FindAllStringSubmatch(svg, -1) {
+			ys = append(ys, m[1])
+		}
+		if len(ys) != 2 {
+			t.Fatalf("got %d era labels, want 2:\n%s", len(ys), svg)
+		}
+		return ys
+	}
+
+	if ys := labelY(false); ys[0] != ys[1] {
+		t.Fatalf("era labels do not overlap without stacking: %v", ys)
+	}
+	if ys := labelY(true); ys[0] == ys[1] {
+		t.Errorf("era labels still overlap with stacking: %v", ys)
+	}
+}
+
+func TestAutoTextContrast(t *testing.T) {
+	tests := []struct {
+		fill string
+		want string
+	}{
+		{"#000000", `style="fill: #ffffff"`},
+		{"#fff", `style="fill: #000000"`},
+		{"rgb(20, 30, 120)", `style="fill: #ffffff"`},
+		{"rgba(250, 220, 90, 0.9)", `style="fill: #000000"`},
+		{"rebeccapurple", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fill, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			tl.SetAutoTextContrast(true)
+			tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "label", Fill: tt.fill, Duration: time.Second})
+			svg, err := tl.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(svg, `style="fill: `+tt.fill+`"`) {
+				t.Errorf("inline fill not set:\n%s", svg)
+			}
+			label := regexp.MustCompile(`<text [^>]*>label</text>`).FindString(svg)
+			if tt.want == "" && strings.Contains(label, "style=") || tt.want != "" && !strings.Contains(label, tt.want) {
+				t.Errorf("label %s, want style %q", label, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventGap(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "first", Duration: 4 * time.Second})
+	tl.GetLastRow().AddEvent(svgtimeline.Event{ID: "second", Duration: 4 * time.Second, Gap: 2 * time.Second})
+	if d := tl.MaxDuration(); d != 10*time.Second {
+		t.Errorf("MaxDuration() = %v, want 10s", d)
+	}
+	// 10s map to 1000 units, so the 2s gap shifts the second event by 200
+	if x, _, _, _, _ := tl.EventBox("second"); x != 10+400+200 {
+		t.Errorf("second event starts at %v, want 610", x)
+	}
+
+	tl.GetLastRow().AddEvent(svgtimeline.Event{Duration: time.Second, Gap: -time.Second})
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error for a negative gap")
+	}
+}
+
+func TestGenerateRows(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetID("stack")
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "short", Duration: 2 * time.Second})
+	tl.AddRow(40, 0).AddEvent(svgtimeline.Event{ID: "long", Duration: 8 * time.Second})
+
+	svgs, err := tl.GenerateRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(svgs) != 2 {
+		t.Fatalf("got %d SVGs, want 2", len(svgs))
+	}
+
+	viewBox := regexp.MustCompile(`viewBox="0 0 ([\d.]+) `)
+	for i, svg := range svgs {
+		if got, want := viewBox.FindString(svg), viewBox.FindString(svgs[0]); got == "" || got != want {
+			t.Errorf("row %d viewBox %q does not share the timeline width %q", i, got, want)
+		}
+		if strings.Contains(svg, `class="tl-axis"`) {
+			t.Errorf("row %d draws the axis:\n%s", i, svg)
+		}
+		if !strings.Contains(svg, fmt.Sprintf(`id="stack-row-%d"`, i)) {
+			t.Errorf("row %d misses its ID:\n%s", i, svg)
+		}
+	}
+	// The short event spans 2s of the 8s scale
+	if !strings.Contains(svgs[0], `width="250"`) {
+		t.Errorf("first row is not drawn with the shared scale:\n%s", svgs[0])
+	}
+}
+
+func TestDateBoundaryTicks(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skip(err)
+	}
+	// Spans the switch to summer time on 2025-03-30, a 23 hour day
+	start := time.Date(2025, 3, 28, 18, 0, 0, 0, madrid)
+	tl := svgtimeline.NewTimeline()
+	tl.SetDateBoundaryTicks(svgtimeline.DateBoundaryDay)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Time: start, Duration: 71 * time.Hour})
+
+	var labels []string
+	for _, tick := range tl.Ticks() {
+		labels = append(labels, tick.Label)
+		if tick.Label != "" {
+			if d := start.Add(tick.Duration); d.Hour() != 0 || d.Minute() != 0 {
+				t.Errorf("tick %q is not at midnight: %v", tick.Label, d)
+			}
+		}
+	}
+	want := []string{"", "2025-03-29", "2025-03-30", "2025-03-31", ""}
+	if !slices.Equal(labels, want) {
+		t.Errorf("got labels %q, want %q", labels, want)
+	}
+
+	tl.SetDateBoundaryTicks(svgtimeline.DateBoundaryHour)
+	ticks := tl.Ticks()
+	// Boundaries at the start and end of the axis are labeled, 71 hours pass in between
+	if len(ticks) != 72 || ticks[0].Label != "18:00" || ticks[6].Label != "2025-03-29" || ticks[7].Label != "01:00" {
+		t.Errorf("unexpected hour ticks: %d ticks, %q %q %q", len(ticks), ticks[0].Label, ticks[6].Label, ticks[7].Label)
+	}
+
+	tl.GetLastRow().AddEvent(svgtimeline.Event{Time: start, Duration: 2000 * time.Hour})
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error for too many boundary ticks")
+	}
+}
+
+func TestMultiLineText(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(60, 5).AddEvent(svgtimeline.Event{Type: svgtimeline.EventTypeEra, Text: "Phase 1\nwarm-up", Duration: 10 * time.Second})
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "single", Duration: 10 * time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<tspan x="510" dy="-0.6em">Phase 1</tspan>`,
+		`<tspan x="510" dy="1.2em">warm-up</tspan>`,
+		`dominant-baseline="middle">single</text>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+	// Three lines share the row height, so the font is smaller than for two
+	label := regexp.MustCompile(`font-size="(\d+)"[^>]*>\s*<tspan`)
+	two := label.FindStringSubmatch(svg)
+
+	tl.GetRowByIndex(0).GetEvents()[0].Text = "a\nb\nc"
+	svg, err = tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	three := label.FindStringSubmatch(svg)
+	if two == nil || three == nil || two[1] != "19" || three[1] != "14" {
+		t.Errorf("got font sizes %v and %v, want 19 and 14", two, three)
+	}
+}
+
+func TestLabelBaseline(t *testing.T) {
+	tests := []struct {
+		baseline string
+		want     string
+	}{
+		{"", `y="45" font-size="15" font-family="monospace" text-anchor="middle" dominant-baseline="middle"`},
+		{"hanging", `y="18" font-size="15" font-family="monospace" text-anchor="middle" dominant-baseline="hanging"`},
+		{"alphabetic", `y="72" font-size="15" font-family="monospace" text-anchor="middle" dominant-baseline="alphabetic"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.baseline, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			tl.SetLabelBaseline(tt.baseline)
+			tl.SetMaxLabelFontSize(15)
+			tl.AddRow(60, 5).AddEvent(svgtimeline.Event{Text: "label", Duration: time.Second})
+			svg, err := tl.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(svg, tt.want) {
+				t.Errorf("output does not contain %q:\n%s", tt.want, svg)
+			}
+		})
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.SetLabelBaseline("top")
+	tl.AddRow(60, 5).AddEvent(svgtimeline.Event{Text: "label", Duration: time.Second})
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error for an unknown baseline")
+	}
+}
+
+func TestAppend(t *testing.T) {
+	base := svgtimeline.NewTimeline()
+	base.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 2 * time.Second})
+
+	other := svgtimeline.NewTimeline()
+	other.SetWidth("500")
+	other.AddRow(40, 0).AddEvent(svgtimeline.Event{Duration: 5 * time.Second})
+
+	base.Append(other)
+	if n := base.RowCount(); n != 2 {
+		t.Fatalf("combined timeline has %d rows, want 2", n)
+	}
+	if d := base.MaxDuration(); d != 5*time.Second {
+		t.Errorf("combined timeline max duration is %v, want 5s", d)
+	}
+	if h := base.TotalRowHeight(); h != 75 {
+		t.Errorf("combined timeline row height is %d, want 75", h)
+	}
+
+	// The rows are copies, changing them leaves the other timeline untouched
+	base.GetLastRow().AddEvent(svgtimeline.Event{Duration: time.Second})
+	if n := other.GetLastRow().EventCount(); n != 1 {
+		t.Errorf("other timeline row has %d events, want 1", n)
+	}
+}
+
+func TestOutline(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "planned", Text: "plan", Fill: "#ff0000", Outline: true, Duration: 2 * time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, `<g id="planned" class="tl-event tl-outline">`) {
+		t.Errorf("outline class not set:\n%s", svg)
+	}
+	if strings.Contains(svg, "fill: #ff0000") {
+		t.Errorf("inline fill set on an outline:\n%s", svg)
+	}
+	if !strings.Contains(svg, ">plan</text>") {
+		t.Errorf("outline label not rendered:\n%s", svg)
+	}
+}
+
+func TestEraInLastRow(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetTickHeight(20)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 2 * time.Second})
+	tl.AddRow(10, 0).AddEvent(svgtimeline.Event{ID: "era", Type: svgtimeline.EventTypeEra, Duration: time.Second})
+	_, _, _, h, ok := tl.EventBox("era")
+	if !ok {
+		t.Fatalf("era not drawn")
+	}
+	if h <= 0 {
+		t.Errorf("era height = %v, want a positive height", h)
+	}
+}
+
+func TestMarkerDefs(t *testing.T) {
+	const arrow = `<marker id="arrow" markerWidth="6" markerHeight="6" refX="6" refY="3" orient="auto"><path d="M0,0 L6,3 L0,6 z"></path></marker>`
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second})
+	tl.AddMarkerDef("arrow", arrow)
+	tl.SetAxisMarkers("", "arrow")
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, arrow) {
+		t.Errorf("marker not injected in the definitions:\n%s", svg)
+	}
+	if !strings.Contains(svg, `marker-end="url(#arrow)"`) || strings.Contains(svg, "marker-start") {
+		t.Errorf("axis markers not set:\n%s", svg)
+	}
+
+	for _, fragment := range []string{
+		`<marker id="arrow"><path></marker>`,
+		`<path id="arrow"></path>`,
+		`<marker id="other"></marker>`,
+		`<marker id="arrow"></marker><marker id="arrow"></marker>`,
+	} {
+		tl := svgtimeline.NewTimeline()
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second})
+		tl.AddMarkerDef("arrow", fragment)
+		if _, err := tl.Generate(); err == nil {
+			t.Errorf("expected an error for the marker %q", fragment)
+		}
+	}
+
+	tl.SetAxisMarkers("missing", "")
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error for an unknown axis marker")
+	}
+}
+
+func TestGenerateJSON(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "first", Text: "one", Class: "db", Duration: 10 * time.Second})
+	tl.GetLastRow().AddEvent(svgtimeline.Event{ID: "second", Duration: 10 * time.Second})
+
+	data, err := tl.GenerateJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var layout svgtimeline.Layout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		t.Fatal(err)
+	}
+	if w, h := tl.Dimensions(); layout.Width != w || layout.Height != h {
+		t.Errorf("canvas = %vx%v, want %vx%v", layout.Width, layout.Height, w, h)
+	}
+	if len(layout.Ticks) != len(tl.Ticks()) {
+		t.Errorf("got %d ticks, want %d", len(layout.Ticks), len(tl.Ticks()))
+	}
+	want := []svgtimeline.EventLayout{
+		{ID: "first", Class: "tl-event db", X: 10, Y: 15, Width: 500, Height: 30, Label: "one"},
+		{ID: "second", Class: "tl-event", X: 510, Y: 15, Width: 500, Height: 30},
+	}
+	if !slices.Equal(layout.Events, want) {
+		t.Errorf("events = %+v, want %+v", layout.Events, want)
+	}
+}
+
+func TestLabelAlways(t *testing.T) {
+	generate := func(always bool) string {
+		tl := svgtimeline.NewTimeline()
+		tl.SetMinLabelFontSize(6)
+		row := tl.AddRow(30, 5)
+		row.AddEvent(svgtimeline.Event{Text: "a long label", Duration: 10 * time.Millisecond, LabelAlways: always})
+		row.AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return svg
+	}
+
+	if svg := generate(false); strings.Contains(svg, "a long label") {
+		t.Errorf("label drawn on a narrow event:\n%s", svg)
+	}
+	svg := generate(true)
+	if !strings.Contains(svg, `<text class="tl-label-outside" x="13.999000999001" y="30" font-size="6" font-family="monospace" text-anchor="start"`) {
+		t.Errorf("label not drawn next to the event at the minimum font size:\n%s", svg)
+	}
+
+	// A label overflowing an event at the right edge is drawn on its left
+	tl := svgtimeline.NewTimeline()
+	tl.SetMinLabelFontSize(6)
+	row := tl.AddRow(30, 5)
+	row.AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+	row.AddEvent(svgtimeline.Event{Text: "a very long label that overflows", Duration: 10 * time.Millisecond, LabelAlways: true})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, `<text class="tl-label-outside" x="1006.000999000999" y="30" font-size="6" font-family="monospace" text-anchor="end"`) {
+		t.Errorf("label not drawn on the left of the event at the right edge:\n%s", svg)
+	}
+
+	// A segment label fitting at a font size of 4 is only drawn above the minimum
+	for minSize, want := range map[int]bool{3: true, 6: false} {
+		tl := svgtimeline.NewTimeline()
+		tl.SetMinLabelFontSize(minSize)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second, Segments: []svgtimeline.Segment{
+			{Duration: 100 * time.Millisecond, Text: "seg"},
+			{Duration: 9900 * time.Millisecond},
+		}})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(svg, ">seg</text>"); got != want {
+			t.Errorf("minimum font size %d: segment label drawn = %v, want %v:\n%s", minSize, got, want, svg)
+		}
+	}
+}
+
+func TestShowOriginLabel(t *testing.T) {
+	start := time.Date(2025, 11, 1, 12, 20, 50, 0, time.UTC)
+	generate := func(show bool) (string, float64) {
+		tl := svgtimeline.NewTimeline()
+		tl.SetShowOriginLabel(show)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second, Time: start})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, h := tl.Dimensions()
+		return svg, h
+	}
+
+	svg, h := generate(true)
+	if !strings.Contains(svg, `<text class="tl-origin" x="10" y="90" font-size="12" font-family="monospace" text-anchor="start">start: 2025-11-01 12:20:50</text>`) {
+		t.Errorf("origin label not drawn:\n%s", svg)
+	}
+	if _, want := generate(false); h != want+15 {
+		t.Errorf("height = %v, want %v", h, want+15)
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.SetShowOriginLabel(true)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+	if svg, _ := tl.Generate(); strings.Contains(svg, `<text class="tl-origin"`) {
+		t.Errorf("origin label drawn in duration mode:\n%s", svg)
+	}
+}
+
+func TestRowStartOffset(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "first", Duration: 10 * time.Second})
+	row := tl.AddRow(30, 5)
+	row.SetStartOffset(5 * time.Second)
+	row.AddEvent(svgtimeline.Event{ID: "second", Duration: 5 * time.Second})
+	row.AddEvent(svgtimeline.Event{ID: "third", Offset: 10 * time.Second, Duration: 5 * time.Second})
+
+	if d := row.TotalDuration(time.Time{}); d != 20*time.Second {
+		t.Errorf("TotalDuration = %v, want 20s", d)
+	}
+	// 20s are drawn over 1000px starting at x=10
+	for id, want := range map[string]float64{"first": 10, "second": 260, "third": 760} {
+		if x, _, _, _, _ := tl.EventBox(id); x != want {
+			t.Errorf("%s starts at x=%v, want %v", id, x, want)
+		}
+	}
+
+	row.SetStartOffset(-time.Second)
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error for a negative start offset")
+	}
+}
+
+func TestClassColors(t *testing.T) {
+	palette := svgtimeline.GeneratePalette(3)
+	if want := []string{"#d74242", "#42d742", "#4242d7"}; !slices.Equal(palette, want) {
+		t.Errorf("GeneratePalette(3) = %v, want %v", palette, want)
+	}
+	if len(svgtimeline.GeneratePalette(0)) != 0 {
+		t.Errorf("GeneratePalette(0) is not empty")
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Class: "db", Duration: time.Second})
+	colors := map[string]string{"db": palette[0], "cache": palette[1]}
+	tl.SetClassColors(colors)
+	colors["db"] = "#000000" // must not leak into the timeline
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ".cache rect,&#xA;.cache polygon {&#xA;  fill: #42d742;&#xA;}&#xA;&#xA;.db rect,&#xA;.db polygon {&#xA;  fill: #d74242;&#xA;}"
+	if !strings.Contains(svg, want) {
+		t.Errorf("class colors not in the style:\n%s", svg)
+	}
+
+	for class, color := range map[string]string{
+		"db rect":   "red",
+		"1st":       "red",
+		"db,.cache": "red",
+		"db{}":      "red",
+		"cache":     "red; } svg { display: none",
+	} {
+		tl.SetClassColors(map[string]string{class: color})
+		if _, err := tl.Generate(); err == nil {
+			t.Errorf("expected an error for the class %q colored %q", class, color)
+		}
+	}
+}
+
+func TestCSSVars(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second})
+	vars := map[string]string{"tl-era-fill": "#eeeeee", "--tl-bar-fill": "teal"}
+	tl.SetCSSVars(vars)
+	vars["--tl-bar-fill"] = "red" // must not leak into the timeline
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<style>:root {&#xA;  --tl-bar-fill: teal;&#xA;  --tl-era-fill: #eeeeee;&#xA;}&#xA;&#xA;.tl-bg {"
+	if !strings.Contains(svg, want) {
+		t.Errorf("output does not start the style with the sorted variables %q:\n%s", want, svg)
+	}
+
+	tl.SetCSSVars(nil)
+	if svg, _ = tl.Generate(); strings.Contains(svg, ":root") {
+		t.Errorf("variables emitted after clearing them:\n%s", svg)
+	}
+
+	for name, val := range map[string]string{
+		"--tl bar":        "teal",
+		"--":              "teal",
+		"tl-bar-fill:red": "teal",
+		"--tl-bar-fill":   "teal; } svg { display: none",
+		"--tl-era-fill":   "{red}",
+	} {
+		tl.SetCSSVars(map[string]string{name: val})
+		if _, err := tl.Generate(); err == nil {
+			t.Errorf("expected an error for the variable %q = %q", name, val)
+		}
+	}
+}
+
+func TestPatternFill(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "plain", Duration: time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(svg, "<pattern") {
+		t.Errorf("patterns emitted without events using them:\n%s", svg)
+	}
+
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "estimated", Duration: time.Second, Pattern: "hatch", Fill: "red"})
+	if svg, err = tl.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<pattern id="tl-pattern-hatch"`,
+		`<pattern id="tl-pattern-dots"`,
+		`<rect x="10" y="50" width="1000" height="30" style="fill: url(#tl-pattern-hatch)"></rect>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second, Pattern: "stripes"})
+	if _, err := tl.Generate(); err == nil || !strings.Contains(err.Error(), "unknown pattern 'stripes'") {
+		t.Errorf("expected an error for an unknown pattern, got %v", err)
+	}
+}
+
+func TestLabelPinning(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetSmallEventLabelMode(svgtimeline.SmallLabelLeader)
+	row := tl.AddRow(30, 5)
+	row.AddEvent(svgtimeline.Event{Text: "first request", Duration: 10 * time.Millisecond})
+	row.AddEvent(svgtimeline.Event{Duration: 5 * time.Second})
+	row.AddEvent(svgtimeline.Event{Text: "middle", Duration: 10 * time.Millisecond})
+	row.AddEvent(svgtimeline.Event{Duration: 5 * time.Second})
+	row.AddEvent(svgtimeline.Event{Text: "last request", Duration: 10 * time.Millisecond})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The labels near the content edges are pinned to them
+	for _, want := range []string{
+		`x="10" y="22" font-size="10" font-family="monospace" text-anchor="start" dominant-baseline="middle">first request</text>`,
+		`x="510" y="22" font-size="10" font-family="monospace" text-anchor="middle" dominant-baseline="middle">middle</text>`,
+		`x="1010" y="22" font-size="10" font-family="monospace" text-anchor="end" dominant-baseline="middle">last request</text>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+}
+
+func TestEmbeddedFont(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetEmbeddedFont("Inter Mono", []byte("woff2"))
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Text: "api", Duration: time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"@font-face {&#xA;  font-family: &#39;Inter Mono&#39;;&#xA;  src: url(data:font/woff2;base64,d29mZjI=) format(&#39;woff2&#39;);&#xA;}",
+		`font-family="&#39;Inter Mono&#39;, monospace"`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+
+	tl.SetEmbeddedFont("", nil)
+	if svg, _ = tl.Generate(); strings.Contains(svg, "@font-face") || !strings.Contains(svg, `font-family="monospace"`) {
+		t.Errorf("embedded font still used after clearing it:\n%s", svg)
+	}
+
+	for name, data := range map[string][]byte{
+		"Inter'; } svg { display: none": []byte("woff2"),
+		"Inter\nMono":                   []byte("woff2"),
+		"Inter":                         nil,
+	} {
+		tl.SetEmbeddedFont(name, data)
+		if _, err := tl.Generate(); err == nil {
+			t.Errorf("expected an error for the font %q with %d bytes", name, len(data))
+		}
+	}
+}
+
+func TestSmallEventLabelMode(t *testing.T) {
+	generate := func(mode svgtimeline.SmallLabelMode) (*svgtimeline.Timeline, string) {
+		tl := svgtimeline.NewTimeline()
+		tl.SetMinLabelFontSize(8)
+		tl.SetSmallEventLabelMode(mode)
+		row := tl.AddRow(30, 5)
+		row.AddEvent(svgtimeline.Event{ID: "wide", Text: "wide event", Duration: 10 * time.Second})
+		for _, id := range []string{"a", "b", "c"} {
+			row.AddEvent(svgtimeline.Event{ID: id, Text: "narrow " + id, Duration: 300 * time.Millisecond})
+		}
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tl, svg
+	}
+
+	_, svg := generate(svgtimeline.SmallLabelHide)
+	if strings.Contains(svg, "narrow") {
+		t.Errorf("narrow labels drawn in hide mode:\n%s", svg)
+	}
+
+	_, svg = generate(svgtimeline.SmallLabelTruncate)
+	if n := strings.Count(svg, `font-size="8" font-family="monospace" text-anchor="middle" dominant-baseline="middle">nar…</text>`); n != 3 {
+		t.Errorf("got %d truncated labels, want 3:\n%s", n, svg)
+	}
+
+	tl, svg := generate(svgtimeline.SmallLabelLeader)
+	re := regexp.MustCompile(`<text class="tl-label-outside" x="[^"]+" y="([^"]+)"[^>]*>(narrow \w)</text>`)
+	lanes := make(map[string]bool)
+	for _, m := range re.FindAllStringSubmatch(svg, -1) {
+		lanes[m[1]] = true
+	}
+	// The adjacent labels overlap each other so each one gets its own lane
+	if len(lanes) != 3 {
+		t.Errorf("leader labels stacked in %d lanes, want 3:\n%s", len(lanes), svg)
+	}
+	if n := strings.Count(svg, `<line class="tl-leader"`); n != 3 {
+		t.Errorf("got %d leader lines, want 3", n)
+	}
+	// The rows are pushed down below the track
+	if _, y, _, _, _ := tl.EventBox("wide"); y != 15+3*14+8 {
+		t.Errorf("first row at y=%v, want %v", y, 15+3*14+8)
+	}
+}
+
+func TestChartTitle(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "first", Duration: time.Second})
+	_, h := tl.Dimensions()
+
+	tl.SetChartTitle("Deploy <prod>", "")
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svg, `<text class="tl-title" x="510" y="23" font-size="16" font-family="monospace" text-anchor="middle" dominant-baseline="middle">Deploy &lt;prod&gt;</text>`) {
+		t.Errorf("title not drawn:\n%s", svg)
+	}
+	if _, got := tl.Dimensions(); got != h+28 {
+		t.Errorf("height = %v, want %v", got, h+28)
+	}
+	if _, y, _, _, _ := tl.EventBox("first"); y != 15+28 {
+		t.Errorf("first row at y=%v, want %v", y, 15+28)
+	}
+
+	tl.SetChartTitle("Deploy", "heading")
+	if svg, _ := tl.Generate(); !strings.Contains(svg, `<text class="heading"`) {
+		t.Errorf("title class not set:\n%s", svg)
+	}
+}
+
+func TestEraBorderStyle(t *testing.T) {
+	tests := []struct {
+		style svgtimeline.EraBorderStyle
+		fill  string
+		want  string
+	}{
+		{svgtimeline.EraBorderOpenSides, "", `<rect x="10" y="15" width="1000" height="40" stroke-dasharray="0,1000.000000,40,0"></rect>`},
+		{svgtimeline.EraBorderFullBox, "", `<rect x="10" y="15" width="1000" height="40"></rect>`},
+		{svgtimeline.EraBorderNone, "", `<rect x="10" y="15" width="1000" height="40" style="stroke: none"></rect>`},
+		{svgtimeline.EraBorderNone, "#ff0000", `<rect x="10" y="15" width="1000" height="40" style="fill: #ff0000; stroke: none"></rect>`},
+	}
+	for _, tt := range tests {
+		tl := svgtimeline.NewTimeline()
+		tl.SetEraBorderStyle(tt.style)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Type: svgtimeline.EventTypeEra, Fill: tt.fill, Duration: time.Second})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(svg, tt.want) {
+			t.Errorf("style %d: era rect not found %s:\n%s", tt.style, tt.want, svg)
+		}
+	}
+}
+
+func TestEventsBeforeStart(t *testing.T) {
+	start := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+	tl := svgtimeline.NewTimeline()
+	tl.SetAllowNegativeDurations(true)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "late", Time: start.Add(10 * time.Second), Duration: 10 * time.Second})
+	row := tl.AddRow(30, 5)
+	row.AddEvent(svgtimeline.Event{ID: "early", Time: start, Duration: 5 * time.Second})
+	row.AddEvent(svgtimeline.Event{ID: "backwards", Time: start.Add(2 * time.Second), Duration: -4 * time.Second})
+
+	check := func() {
+		t.Helper()
+		layout, err := tl.Layout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range layout.Events {
+			if e.X < 10 || e.Width < 0 {
+				t.Errorf("%s drawn at x=%v with width %v, outside of the content area", e.ID, e.X, e.Width)
+			}
+		}
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range regexp.MustCompile(` (?:x|x1|x2)="(-[^"]*)"`).FindAllStringSubmatch(svg, -1) {
+			t.Errorf("negative coordinate %s in:\n%s", m[1], svg)
+		}
+	}
+
+	// The backwards task starts before the earliest event Time
+	check()
+	// Events starting before the visible window are clipped at its start
+	tl.SetTimeWindow(start.Add(3*time.Second), start.Add(20*time.Second))
+	check()
+}
+
+func TestOpacity(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	row := tl.AddRow(30, 5)
+	row.AddEvent(svgtimeline.Event{ID: "solid", Duration: time.Second})
+	row.AddEvent(svgtimeline.Event{ID: "faded", Opacity: 0.5, Duration: time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(svg, `fill-opacity=`); n != 1 {
+		t.Errorf("got %d fill-opacity attributes, want 1:\n%s", n, svg)
+	}
+	if !strings.Contains(svg, `<rect x="510" y="15" width="500" height="30" fill-opacity="0.5"></rect>`) {
+		t.Errorf("opacity not set on the rect:\n%s", svg)
+	}
+
+	// The segments of an event share its opacity
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Opacity: 0.25, Duration: 2 * time.Second, Segments: []svgtimeline.Segment{
+		{Duration: time.Second},
+		{Duration: time.Second},
+	}})
+	if svg, err = tl.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(svg, `<rect class="tl-segment" x=`); n != 2 || n != strings.Count(svg, `height="30" fill-opacity="0.25"></rect>`) {
+		t.Errorf("opacity not set on both segments:\n%s", svg)
+	}
+
+	row.AddEvent(svgtimeline.Event{Opacity: 1.5, Duration: time.Second})
+	if _, err := tl.Generate(); err == nil {
+		t.Errorf("expected an error for an opacity above 1")
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	tl := svgtimeline.NewTimeline()
+	for i := range 100 {
+		row := tl.AddRow(30, 5)
+		if i%10 == 0 {
+			row.AddEvent(svgtimeline.Event{Type: svgtimeline.EventTypeEra, Text: "era", Duration: 50 * time.Second})
+		}
+		for j := range 100 {
+			row.AddEvent(svgtimeline.Event{ID: fmt.Sprintf("e%d-%d", i, j), Text: "task", Duration: time.Second, Pattern: []string{"", "hatch"}[j%2]})
+		}
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := tl.Generate(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestIntegerCoords(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetIntegerCoords(true)
+	tl.SetMaxWidth(1000.5)
+	row := tl.AddRow(30, 5)
+	for range 3 {
+		row.AddEvent(svgtimeline.Event{Text: "task", Duration: time.Second})
+	}
+	tl.AddRow(20, 5).AddEvent(svgtimeline.Event{Type: svgtimeline.EventTypeEra, Text: "era", Duration: 3 * time.Second})
+
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range regexp.MustCompile(` (?:x|y|x1|x2|y1|y2|width|height|cx|cy)="([^"]*)"`).FindAllStringSubmatch(svg, -1) {
+		if strings.Contains(m[1], ".") {
+			t.Errorf("non-integer coordinate %q", m[0])
+		}
+	}
+
+	layout, err := tl.Layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := layout.Events[:3]
+	for i := 1; i < len(tasks); i++ {
+		if end := tasks[i-1].X + tasks[i-1].Width; end != tasks[i].X {
+			t.Errorf("event %d ends at %v but event %d starts at %v", i-1, end, i, tasks[i].X)
+		}
+	}
+}
+
+func TestIsTimeMode(t *testing.T) {
+	start := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		events []svgtimeline.Event
+		want   bool
+	}{
+		{"empty", nil, false},
+		{"durations", []svgtimeline.Event{{Duration: time.Second}, {Duration: time.Second}}, false},
+		{"times", []svgtimeline.Event{{Duration: time.Second, Time: start}, {Duration: time.Second, Time: start.Add(time.Second)}}, true},
+		{"mixed", []svgtimeline.Event{{Duration: time.Second, Time: start}, {Duration: time.Second}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			row := tl.AddRow(20, 5)
+			for _, e := range tt.events {
+				row.AddEvent(e)
+			}
+			if got := tl.IsTimeMode(); got != tt.want {
+				t.Errorf("IsTimeMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViewBoxPadding(t *testing.T) {
+	newTimeline := func(padding float64) *svgtimeline.Timeline {
+		tl := svgtimeline.NewTimeline()
+		tl.SetViewBoxPadding(padding)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "e", Text: "task", Duration: time.Second})
+		return tl
+	}
+
+	w, h := newTimeline(0).Dimensions()
+	x, y, _, _, _ := newTimeline(0).EventBox("e")
+
+	tl := newTimeline(10)
+	pw, ph := tl.Dimensions()
+	if pw != w+20 || ph != h+20 {
+		t.Errorf("padded dimensions %vx%v, want %vx%v", pw, ph, w+20, h+20)
+	}
+	if px, py, _, _, _ := tl.EventBox("e"); px != x || py != y {
+		t.Errorf("padded event at %v,%v, want it unmoved at %v,%v", px, py, x, y)
+	}
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf(`viewBox="-10 -10 %f %f"`, pw, ph); !strings.Contains(svg, want) {
+		t.Errorf("missing %s in:\n%s", want, svg)
+	}
+
+	if _, err := newTimeline(-1).Generate(); err == nil {
+		t.Error("expected an error for a negative padding")
+	}
+}
+
+func TestShowOriginLine(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(svg, `<line class="tl-origin"`) {
+		t.Errorf("origin line drawn by default:\n%s", svg)
+	}
+
+	tl.SetShowOriginLine(true)
+	svg, err = tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin := strings.Index(svg, `<line class="tl-origin" x1="10" y1="15" x2="10" y2="55"></line>`)
+	if origin < 0 {
+		t.Fatalf("origin line not drawn from the top of the rows to the axis:\n%s", svg)
+	}
+	if events := strings.Index(svg, `<g class="tl-event"`); events < origin {
+		t.Errorf("origin line not drawn beneath the events:\n%s", svg)
+	}
+}
+
+func TestBelowAxis(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "gain", Duration: 10 * time.Second})
+	below := tl.AddRow(20, 5)
+	below.SetBelowAxis(true)
+	below.AddEvent(svgtimeline.Event{ID: "loss", Duration: 5 * time.Second})
+	below.AddEvent(svgtimeline.Event{ID: "era", Type: svgtimeline.EventTypeEra, Duration: 5 * time.Second})
+
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`<line class="tl-axis" x1="[^"]*" y1="([^"]*)"`).FindStringSubmatch(svg)
+	if m == nil {
+		t.Fatalf("axis not drawn:\n%s", svg)
+	}
+	axisY, _ := strconv.ParseFloat(m[1], 64)
+
+	_, gainY, _, gainH, _ := tl.EventBox("gain")
+	if gainY+gainH > axisY {
+		t.Errorf("row above the axis ends at %v, below the axis at %v", gainY+gainH, axisY)
+	}
+	_, lossY, _, lossH, _ := tl.EventBox("loss")
+	if lossY <= axisY+15 {
+		t.Errorf("row below the axis starts at %v, over the axis at %v and its labels", lossY, axisY)
+	}
+	if _, h := tl.Dimensions(); lossY+lossH > h {
+		t.Errorf("row below the axis ends at %v, outside of the height %v", lossY+lossH, h)
+	}
+	if _, eraY, _, eraH, _ := tl.EventBox("era"); eraY != lossY || eraH != lossH {
+		t.Errorf("era below the axis at y=%v with height %v, want it covering its row at y=%v with height %v", eraY, eraH, lossY, lossH)
+	}
+}
+
+func TestClipContent(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(svg, "clip-path") {
+		t.Errorf("content clipped by default:\n%s", svg)
+	}
+
+	tl.SetClipContent(true)
+	svg, err = tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<clipPath id="tl-clip-content">`,
+		`<rect x="10" y="0" width="1000" height="85"></rect>`,
+		`<g clip-path="url(#tl-clip-content)">`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+
+	tl.SetID("chart")
+	if svg, _ = tl.Generate(); !strings.Contains(svg, `<clipPath id="chart-clip">`) || !strings.Contains(svg, `clip-path="url(#chart-clip)"`) {
+		t.Errorf("clipPath id not derived from the timeline ID:\n%s", svg)
+	}
+}
+
+func TestEstimateTextWidth(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	ascii := tl.EstimateTextWidth("abc", 10)
+	if ascii <= 0 {
+		t.Fatalf("width of an ASCII text = %v, want positive", ascii)
+	}
+	if got := tl.EstimateTextWidth("äöü", 10); got != ascii {
+		t.Errorf("width of a multibyte text = %v, want %v as the ASCII text with as many characters", got, ascii)
+	}
+	if got := tl.EstimateTextWidth("abc", 20); got != 2*ascii {
+		t.Errorf("width at twice the font size = %v, want %v", got, 2*ascii)
+	}
+	if got := tl.EstimateTextWidth("abc\na", 10); got != ascii {
+		t.Errorf("width of a multi-line text = %v, want the widest line %v", got, ascii)
+	}
+
+	tl.SetGlyphWidthFunc(func(r rune, fontSize float64) float64 {
+		if r >= utf8.RuneSelf {
+			return fontSize
+		}
+		return fontSize / 2
+	})
+	if ascii, wide := tl.EstimateTextWidth("ab", 10), tl.EstimateTextWidth("日本", 10); ascii != 10 || wide != 20 {
+		t.Errorf("widths with the glyph function = %v and %v, want 10 and 20", ascii, wide)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second, Annotations: []svgtimeline.Annotation{
+		{Offset: 2 * time.Second, Label: "first byte"},
+		{Offset: 12 * time.Second, Label: "past the end"},
+	}})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<line x1="210" y1="15" x2="210" y2="45"></line>`,
+		`<text x="213" y="18" font-size="10" font-family="monospace" text-anchor="start" dominant-baseline="hanging">first byte</text>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+	if n := strings.Count(svg, `<g class="tl-annotation">`); n != 1 {
+		t.Errorf("got %d annotations, want the one within the event duration", n)
+	}
+}
+
+func TestDense(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 5 * time.Second})
+	_, normal := tl.Dimensions()
+
+	tl.SetDense(true)
+	_, dense := tl.Dimensions()
+	// Without the separators and with the margins of 15 and the tick label margin of 15 reduced to 4, 4 and 12
+	if want := normal - 2*5 - 2*11 - 3; dense != want {
+		t.Errorf("dense height = %v, want %v (normal height %v)", dense, want, normal)
+	}
+	if _, err := tl.Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tl.SetDense(false)
+	if _, h := tl.Dimensions(); h != normal {
+		t.Errorf("height after disabling the dense mode = %v, want %v", h, normal)
+	}
+}
+
+func TestSnapToTicks(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetNumTicks(4)
+	row := tl.AddRow(30, 5)
+	// Ticks every 250px, the edges at 0, 290, 720 and 1000 snap to 0, 250, 750 and 1000
+	row.AddEvent(svgtimeline.Event{ID: "a", Duration: 2900 * time.Millisecond})
+	row.AddEvent(svgtimeline.Event{ID: "b", Duration: 4300 * time.Millisecond})
+	row.AddEvent(svgtimeline.Event{ID: "c", Duration: 2800 * time.Millisecond})
+
+	want := map[string][2]float64{"a": {10, 250}, "b": {260, 500}, "c": {760, 250}}
+	tl.SetSnapToTicks(true)
+	for id, box := range want {
+		if x, _, w, _, _ := tl.EventBox(id); x != box[0] || w != box[1] {
+			t.Errorf("event %q snapped to x=%v width=%v, want x=%v width=%v", id, x, w, box[0], box[1])
+		}
+	}
+
+	tl.SetSnapToTicks(false)
+	if x, _, w, _, _ := tl.EventBox("a"); x != 10 || w != 290 {
+		t.Errorf("event snapped with the option disabled: x=%v width=%v", x, w)
+	}
+}
+
+func TestSnapToBoundaryTicks(t *testing.T) {
+	start := time.Date(2025, 11, 1, 12, 20, 0, 0, time.UTC)
+	tl := svgtimeline.NewTimeline()
+	tl.SetSnapToTicks(true)
+	tl.SetDateBoundaryTicks(svgtimeline.DateBoundaryHour)
+	row := tl.AddRow(30, 5)
+	row.AddEvent(svgtimeline.Event{ID: "a", Time: start, Duration: 50 * time.Minute})
+	row.AddEvent(svgtimeline.Event{ID: "b", Time: start.Add(50 * time.Minute), Duration: 130 * time.Minute})
+
+	var tickXs []float64
+	for _, tick := range tl.Ticks() {
+		tickXs = append(tickXs, tick.X)
+	}
+	for _, id := range []string{"a", "b"} {
+		x, _, w, _, ok := tl.EventBox(id)
+		if !ok {
+			t.Fatalf("event %q not drawn", id)
+		}
+		for _, edge := range []float64{x, x + w} {
+			if !slices.Contains(tickXs, edge) {
+				t.Errorf("event %q has an edge at %v, not at any of the ticks %v", id, edge, tickXs)
+			}
+		}
+	}
+}
+
+func TestEventOffset(t *testing.T) {
+	tests := []struct {
+		name   string
+		second svgtimeline.Event
+		wantX  float64 // x of the second event
+		wantD  time.Duration
+	}{
+		{"auto", svgtimeline.Event{ID: "b", Duration: 2 * time.Second}, 510, 12 * time.Second},
+		{"unset zero", svgtimeline.Event{ID: "b", Duration: 2 * time.Second, Offset: 0}, 510, 12 * time.Second},
+		{"zero", svgtimeline.Event{ID: "b", Duration: 2 * time.Second, HasOffset: true}, 10, 10 * time.Second},
+		{"overlapping", svgtimeline.Event{ID: "b", Duration: 2 * time.Second, Offset: 4 * time.Second}, 210, 10 * time.Second},
+		{"past the end", svgtimeline.Event{ID: "b", Duration: 2 * time.Second, Offset: 18 * time.Second}, 910, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			row := tl.AddRow(30, 5)
+			row.AddEvent(svgtimeline.Event{ID: "a", Duration: 10 * time.Second})
+			row.AddEvent(tt.second)
+			if d := tl.MaxDuration(); d != tt.wantD {
+				t.Errorf("max duration = %v, want %v", d, tt.wantD)
+			}
+			tl.SetMaxDurationOverride(20 * time.Second)
+			if x, _, _, _, _ := tl.EventBox("b"); x != tt.wantX {
+				t.Errorf("second event at x=%v, want %v", x, tt.wantX)
+			}
+		})
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Second, HasOffset: true, Time: time.Now()})
+	if _, err := tl.Generate(); err == nil {
+		t.Error("expected an error for an explicit offset in time mode")
+	}
+}
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []svgtimeline.Segment
+		wantErr  bool
+		want     []string
+		dontWant []string
+	}{
+		{
+			name:     "matching sum",
+			segments: []svgtimeline.Segment{{Duration: 4 * time.Second, Class: "dns", Text: "dns"}, {Duration: 6 * time.Second, Class: "body"}},
+			want: []string{
+				`<rect class="tl-segment dns" x="10" y="15" width="400" height="30"></rect>`,
+				`<rect class="tl-segment body" x="410" y="15" width="600" height="30"></rect>`,
+				`>dns</text>`,
+			},
+		},
+		{
+			name:     "mismatching sum",
+			segments: []svgtimeline.Segment{{Duration: 4 * time.Second}, {Duration: 5 * time.Second}},
+			wantErr:  true,
+		},
+		{
+			name:     "label too small",
+			segments: []svgtimeline.Segment{{Duration: 10 * time.Millisecond, Text: "tiny"}, {Duration: 9990 * time.Millisecond}},
+			want:     []string{`<rect class="tl-segment" x="10" y="15" width="1" height="30"></rect>`},
+			dontWant: []string{">tiny</text>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second, Segments: tt.segments})
+			svg, err := tl.Generate()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(svg, want) {
+					t.Errorf("output does not contain %q:\n%s", want, svg)
+				}
+			}
+			for _, dontWant := range tt.dontWant {
+				if strings.Contains(svg, dontWant) {
+					t.Errorf("output contains %q:\n%s", dontWant, svg)
+				}
+			}
+		})
+	}
+}
+
+func TestNumberLocale(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.SetNumTicks(1)
+	tl.SetDurationFormat(svgtimeline.DurationFormatDecimalUnit)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 1500 * time.Millisecond})
+
+	for tag, want := range map[language.Tag]string{
+		language.Und:     ">1.5s</text>",
+		language.English: ">1.5s</text>",
+		language.Spanish: ">1,5s</text>",
+	} {
+		tl.SetNumberLocale(tag)
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(svg, want) {
+			t.Errorf("%v: output does not contain %q:\n%s", tag, want, svg)
+		}
+	}
+}
+
+func TestAutoRow(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  float64
+	}{
+		{"no labels", []string{""}, 24},
+		{"single line", []string{"one", "two"}, 24},
+		{"multi-line", []string{"one", "one\ntwo\nthree"}, 48},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			row := tl.AddAutoRow(5)
+			for i, text := range tt.texts {
+				row.AddEvent(svgtimeline.Event{ID: strconv.Itoa(i), Text: text, Duration: 10 * time.Second})
+			}
+			_, _, _, h, ok := tl.EventBox("0")
+			if !ok || h != tt.want {
+				t.Errorf("row height = %v, want %v", h, tt.want)
+			}
+			if _, err := tl.Generate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(-10, 5).AddEvent(svgtimeline.Event{Duration: time.Second})
+	if _, err := tl.Generate(); err == nil {
+		t.Error("expected an error for a negative row height")
+	}
+}
+
+func TestDurationOverrides(t *testing.T) {
+	generate := func(minD, maxD time.Duration) (svgtimeline.Layout, string) {
+		tl := svgtimeline.NewTimeline()
+		tl.SetNumTicks(2)
+		tl.SetMinDurationOverride(minD)
+		tl.SetMaxDurationOverride(maxD)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+		layout, err := tl.Layout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return layout, svg
+	}
+
+	// The minimum extends the axis past the events
+	layout, svg := generate(20*time.Second, 0)
+	if last := layout.Ticks[len(layout.Ticks)-1]; last.Duration != 20*time.Second {
+		t.Errorf("axis ends at %v, want 20s", last.Duration)
+	}
+	if e := layout.Events[0]; e.Width != 500 {
+		t.Errorf("event width = %v, want half of the content", e.Width)
+	}
+	if strings.Contains(svg, `<line class="tl-clip-mark"`) {
+		t.Errorf("clip mark drawn on an event within the axis:\n%s", svg)
+	}
+
+	// A minimum shorter than the events has no effect
+	if layout, _ = generate(5*time.Second, 0); layout.Ticks[len(layout.Ticks)-1].Duration != 10*time.Second {
+		t.Errorf("axis shortened by the minimum duration override")
+	}
+
+	// The maximum clips the event at the content edge, marking it without an inline stroke
+	layout, svg = generate(0, 5*time.Second)
+	if e := layout.Events[0]; e.X+e.Width != 1010 {
+		t.Errorf("clipped event ends at %v, want the content edge 1010", e.X+e.Width)
+	}
+	if !strings.Contains(svg, `<line class="tl-clip-mark" x1="1010" y1="15" x2="1010" y2="45" stroke-width="2" stroke-dasharray="3,2"></line>`) {
+		t.Errorf("clip mark not drawn at the content edge:\n%s", svg)
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.SetMinDurationOverride(20 * time.Second)
+	tl.SetMaxDurationOverride(10 * time.Second)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+	if _, err := tl.Generate(); err == nil {
+		t.Error("expected an error for a minimum greater than the maximum")
+	}
+}
+
+func TestDualAxisLabels(t *testing.T) {
+	generate := func(start time.Time, dual bool) (string, float64) {
+		tl := svgtimeline.NewTimeline()
+		tl.SetNumTicks(2)
+		tl.SetDualAxisLabels(dual)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Time: start, Duration: time.Hour})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, h := tl.Dimensions()
+		return svg, h
+	}
+
+	start := time.Date(2025, 11, 1, 12, 20, 0, 0, time.UTC)
+	svg, h := generate(start, true)
+	for _, want := range []string{">T+0s</text>", ">12:20:00</text>", ">12:50:00</text>", ">13:20:00</text>"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+	if _, want := generate(start, false); h != want+15 {
+		t.Errorf("height = %v, want %v", h, want+15)
+	}
+
+	// Ticks across midnight get the date
+	svg, _ = generate(time.Date(2025, 11, 1, 23, 30, 0, 0, time.UTC), true)
+	for _, want := range []string{">2025-11-01 23:30:00</text>", ">2025-11-02 00:00:00</text>", ">2025-11-02 00:30:00</text>"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("output does not contain %q:\n%s", want, svg)
+		}
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.SetDualAxisLabels(true)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: time.Hour})
+	if svg, _ := tl.Generate(); strings.Contains(svg, ">T+") {
+		t.Errorf("time labels drawn in duration mode:\n%s", svg)
+	}
+}
+
+func TestAddBand(t *testing.T) {
+	start := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		from, to   time.Duration
+		wantRect   string
+		wantNoBand bool
+	}{
+		{"inside", 2 * time.Second, 4 * time.Second, `<rect class="tl-band maintenance" x="210" y="15" width="200" height="40"></rect>`, false},
+		{"partly outside", 8 * time.Second, 15 * time.Second, `<rect class="tl-band maintenance" x="810" y="15" width="200" height="40"></rect>`, false},
+		{"outside", 12 * time.Second, 15 * time.Second, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Time: start, Duration: 20 * time.Second})
+			tl.SetTimeWindow(start, start.Add(10*time.Second))
+			tl.AddBand(start.Add(tt.from), start.Add(tt.to), "maintenance")
+			svg, err := tl.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantNoBand {
+				if strings.Contains(svg, `class="tl-band`) {
+					t.Errorf("band outside of the window drawn:\n%s", svg)
+				}
+				return
+			}
+			if !strings.Contains(svg, tt.wantRect) {
+				t.Errorf("output does not contain %q:\n%s", tt.wantRect, svg)
+			}
+		})
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+	tl.AddBand(start, start.Add(time.Second), "")
+	if _, err := tl.Generate(); err == nil {
+		t.Error("expected an error for a band in duration mode")
+	}
+}
+
+func TestAutoTicks(t *testing.T) {
+	tests := []struct {
+		width, spacing int
+		want           int // number of tick intervals
+	}{
+		{1000, 80, 12},
+		{1000, 250, 4},
+		{100, 80, 2}, // at least 2
+	}
+	for _, tt := range tests {
+		tl := svgtimeline.NewTimeline()
+		tl.SetContentWidth(tt.width)
+		tl.SetAutoTicks(true)
+		tl.SetTargetTickSpacing(tt.spacing)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+		if got := len(tl.Ticks()) - 1; got != tt.want {
+			t.Errorf("width %d, spacing %d: got %d tick intervals, want %d", tt.width, tt.spacing, got, tt.want)
+		}
+	}
+
+	for _, spacing := range []int{0, -10} {
+		tl := svgtimeline.NewTimeline()
+		tl.SetAutoTicks(true)
+		tl.SetTargetTickSpacing(spacing)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+		if _, err := tl.Generate(); err == nil {
+			t.Errorf("expected an error for the tick spacing %d", spacing)
+		}
+	}
+}
+
+func TestNewTimelineWithOptions(t *testing.T) {
+	tl := svgtimeline.NewTimelineWithOptions(
+		svgtimeline.WithID("opts"),
+		svgtimeline.WithPrecision(500),
+		svgtimeline.WithWidth("800"),
+		svgtimeline.WithHeight("200"),
+		svgtimeline.WithNumTicks(4),
+		svgtimeline.WithTickHeight(8),
+		svgtimeline.WithMargins(20, 40, 20, 10),
+		svgtimeline.WithStyle(".tl-event rect { fill: red; }"),
+	)
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+	got, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := svgtimeline.NewTimeline()
+	want.SetID("opts")
+	want.SetPrecision(500)
+	want.SetWidth("800")
+	want.SetHeight("200")
+	want.SetNumTicks(4)
+	want.SetTickHeight(8)
+	want.SetMargins(20, 40, 20, 10)
+	want.SetStyle(".tl-event rect { fill: red; }")
+	want.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+	if svg, _ := want.Generate(); got != svg {
+		t.Errorf("options differ from the setters:\ngot:\n%s\nwant:\n%s", got, svg)
+	}
+	if w, _ := tl.Dimensions(); w != 550 {
+		t.Errorf("width = %v, want 550 from the precision and the margins", w)
+	}
+	if ticks := tl.Ticks(); len(ticks) != 5 {
+		t.Errorf("got %d ticks, want 5", len(ticks))
+	}
+	for _, attr := range []string{`id="opts"`, `width="800"`, `height="200"`, "fill: red;"} {
+		if !strings.Contains(got, attr) {
+			t.Errorf("output does not contain %q:\n%s", attr, got)
+		}
+	}
+
+	tl = svgtimeline.NewTimelineWithOptions(svgtimeline.WithPrecision(100), svgtimeline.WithMargins(10, -200, 10, 10))
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+	if _, err := tl.Generate(); err == nil {
+		t.Error("expected an error for margins leaving no space")
+	}
+}
+
+func TestEventShapes(t *testing.T) {
+	tests := []struct {
+		shape svgtimeline.EventShape
+		want  string
+	}{
+		{svgtimeline.ShapeRect, `<rect x="10" y="15" width="1000" height="30"></rect>`},
+		{svgtimeline.ShapeRounded, `<rect x="10" y="15" width="1000" height="30" rx="7.5" ry="7.5"></rect>`},
+		{svgtimeline.ShapeChevron, `<polygon points="10,15 995,15 1010,30 995,45 10,45 25,30"></polygon>`},
+	}
+	for _, tt := range tests {
+		tl := svgtimeline.NewTimeline()
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second, Shape: tt.shape})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(svg, tt.want) {
+			t.Errorf("shape %d: output does not contain %q:\n%s", tt.shape, tt.want, svg)
+		}
+	}
+
+	// Eras ignore the shape
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Type: svgtimeline.EventTypeEra, Duration: 10 * time.Second, Shape: svgtimeline.ShapeChevron})
+	if svg, _ := tl.Generate(); strings.Contains(svg, "<polygon") {
+		t.Errorf("era drawn as a chevron:\n%s", svg)
+	}
+
+	tl = svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second, Shape: svgtimeline.EventShape(42)})
+	if _, err := tl.Generate(); err == nil {
+		t.Error("expected an error for an unknown shape")
+	}
+}
+
+func TestTickLabelSkipOverlap(t *testing.T) {
+	generate := func(skip bool) (string, []svgtimeline.Tick) {
+		tl := svgtimeline.NewTimeline()
+		tl.SetNumTicks(50) // 20px apart, narrower than the labels
+		tl.SetTickLabelSkipOverlap(skip)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Duration: 10 * time.Second})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return svg, tl.Ticks()
+	}
+
+	svg, ticks := generate(true)
+	var labeled int
+	for _, tick := range ticks {
+		if tick.Label != "" {
+			labeled++
+		}
+	}
+	if labeled == 0 || labeled == len(ticks) {
+		t.Errorf("got %d labeled ticks out of %d, want some of them skipped", labeled, len(ticks))
+	}
+	axis := svg[strings.Index(svg, `<g class="tl-ticks">`):]
+	if got := strings.Count(axis, "<line "); got != len(ticks) {
+		t.Errorf("got %d tick marks, want %d", got, len(ticks))
+	}
+	if got := strings.Count(axis, "<text "); got != labeled {
+		t.Errorf("got %d tick labels drawn, want %d", got, labeled)
+	}
+
+	if _, ticks := generate(false); ticks[1].Label == "" {
+		t.Error("tick labels skipped with the option disabled")
+	}
+}
+
+func TestTooltipIncludeDuration(t *testing.T) {
+	start := time.Date(2025, 11, 1, 12, 20, 50, 0, time.UTC)
+	tests := []struct {
+		name  string
+		event svgtimeline.Event
+		want  string
+	}{
+		{"duration mode", svgtimeline.Event{Title: "fetch", Duration: 1500 * time.Millisecond}, "<title>fetch&#xA;duration: 1.5s</title>"},
+		{"without title", svgtimeline.Event{Duration: 1500 * time.Millisecond}, "<title>duration: 1.5s</title>"},
+		{"time mode", svgtimeline.Event{Title: "fetch", Duration: 1500 * time.Millisecond, Time: start}, "<title>fetch&#xA;duration: 1.5s&#xA;start: 2025-11-01 12:20:50&#xA;end: 2025-11-01 12:20:51.5</title>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := svgtimeline.NewTimeline()
+			tl.AddRow(30, 5).AddEvent(tt.event)
+			svg, err := tl.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(svg, "duration: ") {
+				t.Errorf("duration in the tooltip by default:\n%s", svg)
+			}
+
+			tl.SetTooltipIncludeDuration(true)
+			if svg, err = tl.Generate(); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(svg, tt.want) {
+				t.Errorf("output does not contain %q:\n%s", tt.want, svg)
+			}
+		})
+	}
+}
+
+func TestEraLabelPosition(t *testing.T) {
+	for pos, want := range map[svgtimeline.EraLabelPosition]string{
+		svgtimeline.EraLabelTop:    `y="25"`,
+		svgtimeline.EraLabelCenter: `y="35"`,
+		svgtimeline.EraLabelBottom: `y="45"`,
+	} {
+		tl := svgtimeline.NewTimeline()
+		tl.SetEraLabelPosition(pos)
+		tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Type: svgtimeline.EventTypeEra, Text: "era", Duration: 10 * time.Second})
+		svg, err := tl.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := regexp.MustCompile(`<text x="[^"]*" (y="[^"]*")[^>]*>era</text>`).FindStringSubmatch(svg); m == nil || m[1] != want {
+			t.Errorf("position %d: got era label %q, want %s:\n%s", pos, m, want, svg)
+		}
+	}
+
+	tl := svgtimeline.NewTimeline()
+	tl.SetEraLabelPosition(svgtimeline.EraLabelPosition(42))
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{Type: svgtimeline.EventTypeEra, Text: "era", Duration: 10 * time.Second})
+	if _, err := tl.Generate(); err == nil {
+		t.Error("expected an error for an unknown era label position")
+	}
+}
+
+func TestAddTaskAddEra(t *testing.T) {
+	start := time.Date(2025, 11, 1, 12, 20, 50, 0, time.UTC)
+	tests := []struct {
+		name    string
+		add     func(*svgtimeline.Row) error
+		want    svgtimeline.Event
+		wantErr bool
+	}{
+		{
+			name: "task",
+			add:  func(r *svgtimeline.Row) error { return r.AddTask(start, start.Add(3*time.Second), "fetch", "db") },
+			want: svgtimeline.Event{Type: svgtimeline.EventTypeTask, Text: "fetch", Class: "db", Time: start, Duration: 3 * time.Second},
+		},
+		{
+			name: "era",
+			add:  func(r *svgtimeline.Row) error { return r.AddEra(start, start.Add(time.Minute), "phase", "") },
+			want: svgtimeline.Event{Type: svgtimeline.EventTypeEra, Text: "phase", Time: start, Duration: time.Minute},
+		},
+		{
+			name:    "end before start",
+			add:     func(r *svgtimeline.Row) error { return r.AddTask(start, start.Add(-time.Second), "", "") },
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			add:     func(r *svgtimeline.Row) error { return r.AddEra(start, start, "", "") },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := svgtimeline.NewTimeline().AddRow(30, 5)
+			err := tt.add(row)
+			if tt.wantErr {
+				if err == nil || row.EventCount() != 0 {
+					t.Errorf("got error %v with %d events, want an error and no events", err, row.EventCount())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			long := svgtimeline.NewTimeline().AddRow(30, 5)
+			long.AddEvent(tt.want)
+			if got, want := row.GetEvents(), long.GetEvents(); !reflect.DeepEqual(got, want) {
+				t.Errorf("got events %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestTargetHighlight(t *testing.T) {
+	tl := svgtimeline.NewTimeline()
+	tl.AddRow(30, 5).AddEvent(svgtimeline.Event{ID: "my-event", Duration: 10 * time.Second})
+	svg, err := tl.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(svg, ":target") {
+		t.Errorf("target rule emitted by default:\n%s", svg)
+	}
+
+	tl.SetTargetHighlight(true)
+	if svg, err = tl.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	want := ".tl-event:target rect,&#xA;.tl-event:target polygon,&#xA;.tl-era:target rect {&#xA;  stroke: var(--tl-target-stroke, #ff8800);"
+	if !strings.Contains(svg, want) {
+		t.Errorf("output does not contain the target rule %q:\n%s", want, svg)
+	}
+	if n := strings.Count(svg, ".tl-era:target rect"); n != 1 {
+		t.Errorf("target rule emitted %d times, want once", n)
+	}
+}
@@ -0,0 +1,51 @@
+(function () {
+  var script = document.currentScript;
+  var root = script ? script.ownerSVGElement : null;
+  if (!root) return;
+  var tip = root.querySelector(".tl-tooltip");
+  var bg = tip.querySelector("rect");
+  var label = tip.querySelector("text");
+
+  function show(ev) {
+    var el = ev.currentTarget;
+    while (label.firstChild) label.removeChild(label.firstChild);
+    el.getAttribute("data-tooltip").split("\n").forEach(function (s, i) {
+      var span = document.createElementNS("http://www.w3.org/2000/svg", "tspan");
+      span.setAttribute("x", "0");
+      span.setAttribute("dy", i === 0 ? "0" : "1.2em");
+      span.textContent = s;
+      label.appendChild(span);
+    });
+    tip.style.display = "inline";
+    var box = label.getBBox();
+    bg.setAttribute("x", box.x - 4);
+    bg.setAttribute("y", box.y - 4);
+    bg.setAttribute("width", box.width + 8);
+    bg.setAttribute("height", box.height + 8);
+    move(ev);
+  }
+
+  function move(ev) {
+    var m = root.getScreenCTM();
+    if (!m) return;
+    var p = root.createSVGPoint();
+    p.x = ev.clientX;
+    p.y = ev.clientY;
+    p = p.matrixTransform(m.inverse());
+    var box = bg.getBBox();
+    var vb = root.viewBox.baseVal;
+    var x = Math.min(p.x + 12, vb.x + vb.width - box.width);
+    var y = Math.min(p.y + 20, vb.y + vb.height - box.height);
+    tip.setAttribute("transform", "translate(" + x + "," + y + ")");
+  }
+
+  function hide() {
+    tip.style.display = "none";
+  }
+
+  root.querySelectorAll("[data-tooltip]").forEach(function (el) {
+    el.addEventListener("mouseenter", show);
+    el.addEventListener("mousemove", move);
+    el.addEventListener("mouseleave", hide);
+  });
+})();